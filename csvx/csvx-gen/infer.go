@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Collects statistics about a column's values for inferring its type.
+type column struct {
+	n       int  // Number of non-empty values
+	empty   bool // Some values are empty
+	isInt   bool // All non-empty values are ints
+	isFloat bool // All non-empty values are floats
+	isBool  bool // All non-empty values are bools
+}
+
+// Returns a column that has seen no values.
+func newColumn() *column {
+	return &column{isInt: true, isFloat: true, isBool: true}
+}
+
+// Adds a value to the column's statistics.
+func (c *column) add(s string) {
+	if s == "" {
+		c.empty = true
+		return
+	}
+	c.n++
+	if hasLeadingZero(s) { // IDs, zip codes, etc.
+		c.isInt, c.isFloat = false, false
+	}
+	if c.isInt {
+		_, err := strconv.ParseInt(s, 10, 64)
+		c.isInt = err == nil
+	}
+	if c.isFloat {
+		_, err := strconv.ParseFloat(s, 64)
+		c.isFloat = err == nil
+	}
+	if c.isBool {
+		_, err := strconv.ParseBool(s)
+		c.isBool = err == nil
+	}
+}
+
+// Checks whether s is a number padded with leading zeros, like 0123.
+// Such values are decoded as octal by csvx, if at all.
+func hasLeadingZero(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	return len(s) >= 2 && s[0] == '0' && s[1] >= '0' && s[1] <= '9'
+}
+
+// Returns the Go type for the column's values.
+// Columns with empty values get pointer types, which remain nil on empty
+// input.
+func (c *column) goType() string {
+	t := "string"
+	switch {
+	case c.n == 0:
+	case c.isInt:
+		t = "int"
+	case c.isFloat:
+		t = "float64"
+	case c.isBool:
+		t = "bool"
+	}
+	if c.empty && t != "string" {
+		t = "*" + t
+	}
+	return t
+}
+
+// Returns formatted Go source for a struct with the given name, that
+// matches the given columns. If pkg is not empty, a package clause is
+// added.
+func generate(pkg, name string, cols []string, types []*column) (
+	[]byte, error) {
+	buf := &bytes.Buffer{}
+	if pkg != "" {
+		fmt.Fprintf(buf, "package %s\n\n", pkg)
+	}
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+	used := map[string]bool{}
+	count := map[string]int{}
+	for _, col := range cols {
+		count[col]++
+	}
+	for i, col := range cols {
+		fname := fieldName(col, i)
+		for j := 2; used[fname]; j++ {
+			fname = fmt.Sprintf("%s%d", fieldName(col, i), j)
+		}
+		used[fname] = true
+		tag := columnTag(col, i)
+		if count[col] > 1 { // A name tag would match all of them.
+			tag = strconv.Itoa(i)
+		}
+		fmt.Fprintf(buf, "%s %s `csvx:\"%s\"`\n",
+			fname, types[i].goType(), tag)
+	}
+	fmt.Fprintln(buf, "}")
+	return format.Source(buf.Bytes())
+}
+
+// Returns an exported Go identifier for the given column name.
+// I is the column's index, used when the name has no usable characters.
+func fieldName(col string, i int) string {
+	words := strings.FieldsFunc(col, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for j, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[j] = string(r)
+	}
+	s := strings.Join(words, "")
+	if s == "" {
+		return fmt.Sprintf("Column%d", i)
+	}
+	if !unicode.IsLetter([]rune(s)[0]) || !unicode.IsUpper([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// Returns the tag that matches the given column name.
+// Names that cannot be expressed in a tag are matched by the column's
+// index instead.
+func columnTag(col string, i int) string {
+	if col == "" || col == "-" || strings.ContainsAny(col, ",\"`\\:|") ||
+		strings.HasSuffix(col, "*") || strings.HasPrefix(col, "/") ||
+		isNumeric(col) {
+		return strconv.Itoa(i)
+	}
+	return col
+}
+
+// Returns true if the given string contains only digits.
+func isNumeric(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
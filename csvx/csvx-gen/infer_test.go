@@ -0,0 +1,90 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestColumnGoType(t *testing.T) {
+	tests := []struct {
+		input []string
+		want  string
+	}{
+		{[]string{"1", "-2", "0"}, "int"},
+		{[]string{"1", "2.5", "1e3"}, "float64"},
+		{[]string{"true", "F", "false"}, "bool"},
+		{[]string{"1", "true"}, "bool"},
+		{[]string{"1", "a"}, "string"},
+		{[]string{"1", "", "3"}, "*int"},
+		{[]string{"1.5", ""}, "*float64"},
+		{[]string{"a", ""}, "string"},
+		{[]string{"", ""}, "string"},
+		{nil, "string"},
+		{[]string{"08544", "12345"}, "string"},
+		{[]string{"010", "1"}, "string"},
+		{[]string{"-007"}, "string"},
+		{[]string{"0089.5"}, "string"},
+		{[]string{"0", "-0", "0.5"}, "float64"},
+	}
+	for _, test := range tests {
+		c := newColumn()
+		for _, s := range test.input {
+			c.add(s)
+		}
+		if got := c.goType(); got != test.want {
+			t.Errorf("goType(%q)=%q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"name", "Name"},
+		{"sample_id", "SampleId"},
+		{"Sample ID", "SampleID"},
+		{"2x", "X2x"},
+		{"__", "Column3"},
+		{"", "Column3"},
+	}
+	for _, test := range tests {
+		if got := fieldName(test.input, 3); got != test.want {
+			t.Errorf("fieldName(%q)=%q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	cols := []string{"id", "a,b", "x", "x", "7", "v*", "c|d", "/e/"}
+	types := make([]*column, len(cols))
+	for i := range types {
+		types[i] = newColumn()
+		types[i].add("1")
+	}
+	src, err := generate("foo", "Row", cols, types)
+	if err != nil {
+		t.Fatalf("generate(%q) failed: %v", cols, err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", src, 0); err != nil {
+		t.Fatalf("generate(%q) produced invalid source: %v\n%s", cols, err, src)
+	}
+	for _, want := range []string{
+		"package foo",
+		"Id int `csvx:\"id\"`",
+		"AB int `csvx:\"1\"`",
+		"X  int `csvx:\"2\"`",
+		"X2 int `csvx:\"3\"`",
+		"X7 int `csvx:\"4\"`",
+		"V  int `csvx:\"5\"`",
+		"CD int `csvx:\"6\"`",
+		"E  int `csvx:\"7\"`",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generate(%q)=\n%s\nwant it to contain %q", cols, src, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Command csvx-gen generates a Go struct for decoding a CSV file with
+// csvx.
+//
+// It samples the file's first rows, infers each column's type and prints
+// a struct with csvx tags, for use with csvx.DecodeFileHeader.
+//
+// Usage:
+//
+//	csvx-gen [flags] file
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fluhus/gostuff/csvx"
+)
+
+var (
+	tsv   = flag.Bool("t", false, "Input is tab-separated")
+	nrows = flag.Int("n", 1000, "Number of rows to sample, 0 for all rows")
+	name  = flag.String("s", "Row", "Name of the generated struct")
+	pkg   = flag.String("p", "", "Package name, omit for no package clause")
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+	if *nrows < 0 {
+		die("Error: bad number of rows:", *nrows)
+	}
+
+	var mods []csvx.ReaderModifier
+	if *tsv {
+		mods = append(mods, csvx.TSV)
+	}
+	var cols []string
+	var types []*column
+	i := 0
+	for e, err := range csvx.File(flag.Arg(0), mods...) {
+		if err != nil {
+			die("Error:", err)
+		}
+		if cols == nil {
+			cols = e
+			types = make([]*column, len(cols))
+			for j := range types {
+				types[j] = newColumn()
+			}
+			continue
+		}
+		if len(e) != len(cols) {
+			die(fmt.Sprintf("Error: row %d has %d columns, expected %d",
+				i+1, len(e), len(cols)))
+		}
+		for j, s := range e {
+			types[j].add(s)
+		}
+		i++
+		if i == *nrows {
+			break
+		}
+	}
+	if cols == nil {
+		die("Error: input has no header line")
+	}
+
+	src, err := generate(*pkg, *name, cols, types)
+	if err != nil {
+		die("Error:", err)
+	}
+	os.Stdout.Write(src)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Generates a Go struct for decoding a CSV file "+
+		"with csvx.")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  csvx-gen [flags] file")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Flags:")
+	flag.PrintDefaults()
+}
+
+// die reports an error message and exits with error code 2.
+// Arguments are treated like Println.
+func die(a ...any) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(2)
+}
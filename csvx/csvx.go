@@ -2,6 +2,7 @@ package csvx
 
 import (
 	"encoding/csv"
+	"errors"
 	"io"
 	"iter"
 
@@ -11,7 +12,27 @@ import (
 // Reader iterates over CSV entries from a reader.
 // Applies the given modifiers before iteration.
 func Reader(r io.Reader, mods ...ReaderModifier) iter.Seq2[[]string, error] {
-	return func(yield func([]string, error) bool) {
+	return recordFields(records(r, mods))
+}
+
+// File iterates over CSV entries from a file.
+// Applies the given modifiers before iteration.
+func File(file string, mods ...ReaderModifier) iter.Seq2[[]string, error] {
+	return recordFields(fileRecords(file, mods))
+}
+
+// A CSV entry with its line number.
+type record struct {
+	fields []string
+	line   int // 1-based line number where the entry starts
+}
+
+// Iterates over CSV entries from a reader, with their line numbers.
+//
+// Parse errors are yielded and iteration continues,
+// while I/O errors stop the iteration.
+func records(r io.Reader, mods []ReaderModifier) iter.Seq2[record, error] {
+	return func(yield func(record, error) bool) {
 		c := csv.NewReader(r)
 		for _, mod := range mods {
 			mod(c)
@@ -21,33 +42,46 @@ func Reader(r io.Reader, mods ...ReaderModifier) iter.Seq2[[]string, error] {
 			if err == io.EOF {
 				return
 			}
-			if !yield(e, nil) {
+			// Inconsistent field counts are left for the caller to handle.
+			if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+				if !yield(record{}, err) {
+					return
+				}
+				if _, ok := err.(*csv.ParseError); ok {
+					continue
+				}
+				return
+			}
+			line, _ := c.FieldPos(0)
+			if !yield(record{e, line}, nil) {
 				return
 			}
 		}
 	}
 }
 
-// File iterates over CSV entries from a file.
-// Applies the given modifiers before iteration.
-func File(file string, mods ...ReaderModifier) iter.Seq2[[]string, error] {
-	return func(yield func([]string, error) bool) {
+// Iterates over CSV entries from a file, with their line numbers.
+func fileRecords(file string, mods []ReaderModifier) iter.Seq2[record, error] {
+	return func(yield func(record, error) bool) {
 		f, err := aio.Open(file)
 		if err != nil {
-			yield(nil, err)
+			yield(record{}, err)
 			return
 		}
 		defer f.Close()
-		c := csv.NewReader(f)
-		for _, mod := range mods {
-			mod(c)
-		}
-		for {
-			e, err := c.Read()
-			if err == io.EOF {
+		for e, err := range records(f, mods) {
+			if !yield(e, err) {
 				return
 			}
-			if !yield(e, nil) {
+		}
+	}
+}
+
+// Strips the line numbers from an iterator over entries.
+func recordFields(r iter.Seq2[record, error]) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		for e, err := range r {
+			if !yield(e.fields, err) {
 				return
 			}
 		}
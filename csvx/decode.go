@@ -6,11 +6,39 @@
 // # Decode Accepted Types
 //
 // The T type parameter for Decode functions accepts structs.
-// Fields may be of types bool, int*, uint*, float* or string
-// for automatic parsing.
+// Fields may be of types bool, int*, uint*, float*, string,
+// [time.Time], [time.Duration] or any type whose pointer implements
+// [encoding.TextUnmarshaler] for automatic parsing,
+// or pointers to these types.
+// A pointer field remains nil if its input value is empty.
+// Time values are parsed as [time.RFC3339] unless given a layout modifier.
 // For manual parsing with a method, any type is allowed.
 // Unexported fields are ignored.
 //
+// # Nested Structs
+//
+// Fields of embedded structs are treated as fields of the embedding struct.
+// Fields of named struct fields match columns prefixed with the struct
+// field's name and a dot. For example:
+//
+//	type address struct {
+//	  City string
+//	}
+//
+//	type a struct {
+//	  Name string
+//	  Home address                 // Home.City matches home.city, Home.City, etc.
+//	  Work *address `csvx:"office"` // Work.City matches office.city, office.CITY, etc.
+//	}
+//
+// A tagged prefix is matched case sensitively, while untagged parts are
+// matched case insensitively.
+// In no-header mode, nested fields take consecutive columns like other
+// fields.
+// Fields under a struct pointer are skipped on empty input,
+// so that the pointer remains nil if all its fields' inputs are empty.
+// Recursive types, where a struct contains itself, are an error.
+//
 // # Decode Default Behavior
 //
 // [DecodeFile] and [DecodeReader] match column to field according
@@ -32,10 +60,25 @@
 //	  Height float64 // Matches a column titled height, Height, hEiGhT, etc.
 //	}
 //
+// [DecodeFileMaps] and [DecodeMaps] yield each row as a map from column
+// name to value, for when the columns are not known in advance.
+// If column names repeat, the last column with the name is used.
+//
+// A field with alternative names or a regular expression must match at
+// most one column, otherwise decoding fails.
+// Both are header-mode features, and are not allowed in no-header mode.
+//
 // Note that the Header functions use the first line as metadata,
 // while the other two functions expect data starting from the first line.
 // In all functions yielding continues upon parsing errors,
 // so that a caller may choose to skip lines.
+// Use [Strict] for stopping at the first error.
+// Parsing errors are of type [*DecodeError], which reports where in the
+// input the error occurred.
+//
+// [DecodeFileParallel] and [DecodeFileHeaderParallel] decode large files
+// on several goroutines, yielding the same values and errors in the same
+// order as their single-goroutine counterparts.
 //
 // # Decode Field Tags
 //
@@ -49,38 +92,121 @@
 //   - column name or index: associate this field with the column with this
 //     name or at this 0-based index, case sensitively
 //   - "-": a single hyphen, ignore this field entirely
+//   - name prefix followed by "*": gather all columns whose names start
+//     with this prefix into a slice field, by their order
+//   - "lo:hi" or "lo:": gather the columns at these 0-based indexes
+//     (excluding hi) into a slice field, "lo:" meaning until the last column
+//   - alternative names separated by "|": associate this field with the
+//     column with any of these names, case sensitively,
+//     for example "sample_id|SampleID|sample"
+//   - regular expression between slashes: associate this field with the
+//     column whose entire name matches the expression,
+//     for example "/sample[_ ]?id/"; the expression may not contain commas
 //
 // Modifiers may be:
 //   - "allowempty": the input value may be empty, in which case no parsing
 //     will be attempted
 //   - "optional": don't err if the column for this field is missing
+//   - "sep=separator": parse the input value into a slice field, splitting
+//     it on the separator, for example "sep=;" for "1;2;3", or "sep=,,"
+//     for "1,2,3"
+//   - "layout=layout": parse a time field with this [time.Parse] layout,
+//     for example "layout=2006-01-02", or "layout=Jan 2,, 2006" for
+//     "Jan 2, 2006"
+//   - "default=value": use this value when the input value is empty
+//   - "rest": collect all columns that no other field matched into a
+//     map[string]string field, keyed by column name, or by column index
+//     in no-header mode; the column part must be empty
 //   - exported method name: use T's method with this name to parse the
 //     input value
 //
+// Since commas separate the parts of a tag, a comma within a part is
+// written as two commas.
+//
 // A custom parsing method will replace the default parsing.
 // The method's signature must take a string as input,
 // and return the field's type and an error.
+//
+// Slice fields must use either a separator or gather columns, and their
+// elements may be of any of the automatically parsed types.
+// An empty input value yields a nil slice when using a separator.
+// For example:
+//
+//	type a struct {
+//	  Tags    []string  `csvx:",sep=;"`    // "a;b;c" -> {"a","b","c"}
+//	  Samples []float64 `csvx:"sample_*"`  // sample_1, sample_2, ...
+//	  Rest    []int     `csvx:"3:"`        // Columns 3 and onwards
+//	}
+//
+// # Encoding
+//
+// [EncodeFile] and [EncodeWriter] write instances of T with a header line,
+// so that the output can be read back with the Header decode functions.
+// Each field is written under its tag's column name if it has one,
+// otherwise under the field's name.
+// Fields with alternative names are written under the first name.
+// Fields with a column index tag are written at that index, and the other
+// fields fill the remaining columns in order.
+// A rest field is written as columns named after its map's keys, after the
+// other fields' columns and in sorted order. The keys are taken from the
+// first instance, and other instances may not have keys that it lacks.
+// Fields of the automatically parsed types are formatted automatically.
+// Text unmarshaler types are formatted with [encoding.TextMarshaler].
+//
+// The encoding counterpart of a parsing method is the "format=" modifier:
+//
+//	Field int `csvx:"column,ParseField,format=FormatField"`
+//
+// The format method's signature must take the field's type as input,
+// and return a string. The decode functions ignore this modifier.
+//
+// # Fixed-Width Text
+//
+// [DecodeFixedFile] and [DecodeFixedReader] decode fixed-width text,
+// where each field takes the characters in the span given by its "cols"
+// modifier. Spans are 0-based and inclusive, and may be open-ended.
+// For example:
+//
+//	type a struct {
+//	  Name  string  `csvx:",cols=0-9"`   // Characters 0 to 9
+//	  Score float64 `csvx:",cols=10-19"` // Characters 10 to 19
+//	  Notes string  `csvx:",cols=20-"`   // Characters 20 to the end
+//	}
+//
+// [DecodeFixedFileHeader] and [DecodeFixedReaderHeader] detect the columns
+// from the alignment of the first lines, and match them to fields by the
+// first line like the other Header functions.
+// Values are trimmed of surrounding whitespace, and the other tag features
+// apply as usual.
+//
+// # Generating Structs
+//
+// The csvx-gen command infers column types from a sample of a file,
+// and prints a matching struct with csvx tags:
+//
+//	go run github.com/fluhus/gostuff/csvx/csvx-gen data.csv
 package csvx
 
 import (
+	"encoding"
+	"encoding/csv"
 	"fmt"
 	"io"
 	"iter"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// TODO(amit): Struct pointers?
-// TODO(amit): Handle slices?
-
 // DecodeFile returns an iterator over parsed instances of T,
 // using column numbers for matching columns to fields.
 //
 // fn is an optional function for modifying the CSV parser,
 // for example for changing the delimiter.
 func DecodeFile[T any](file string, mods ...ReaderModifier) iter.Seq2[T, error] {
-	return read[T](File(file, mods...), false)
+	return read[T](fileRecords(file, mods), false)
 }
 
 // DecodeReader returns an iterator over parsed instances of T,
@@ -89,7 +215,7 @@ func DecodeFile[T any](file string, mods ...ReaderModifier) iter.Seq2[T, error]
 // fn is an optional function for modifying the CSV parser,
 // for example for changing the delimiter.
 func DecodeReader[T any](r io.Reader, mods ...ReaderModifier) iter.Seq2[T, error] {
-	return read[T](Reader(r, mods...), false)
+	return read[T](records(r, mods), false)
 }
 
 // DecodeFileHeader returns an iterator over parsed instances of T,
@@ -98,7 +224,7 @@ func DecodeReader[T any](r io.Reader, mods ...ReaderModifier) iter.Seq2[T, error
 // fn is an optional function for modifying the CSV parser,
 // for example for changing the delimiter.
 func DecodeFileHeader[T any](file string, mods ...ReaderModifier) iter.Seq2[T, error] {
-	return read[T](File(file, mods...), true)
+	return read[T](fileRecords(file, mods), true)
 }
 
 // DecodeReaderHeader returns an iterator over parsed instances of T,
@@ -107,29 +233,76 @@ func DecodeFileHeader[T any](file string, mods ...ReaderModifier) iter.Seq2[T, e
 // fn is an optional function for modifying the CSV parser,
 // for example for changing the delimiter.
 func DecodeReaderHeader[T any](r io.Reader, mods ...ReaderModifier) iter.Seq2[T, error] {
-	return read[T](Reader(r, mods...), true)
+	return read[T](records(r, mods), true)
 }
 
-// Turns an iterator over string slices into an iterator over T.
-func read[T any](r iter.Seq2[[]string, error], header bool) iter.Seq2[T, error] {
+// DecodeFileMaps returns an iterator over the rows of a file as maps
+// from column name to value, using the first line as the header.
+//
+// fn is an optional function for modifying the CSV parser,
+// for example for changing the delimiter.
+func DecodeFileMaps(file string, mods ...ReaderModifier) iter.Seq2[map[string]string, error] {
+	return readMaps(fileRecords(file, mods))
+}
+
+// DecodeMaps returns an iterator over the rows of a reader as maps
+// from column name to value, using the first line as the header.
+//
+// fn is an optional function for modifying the CSV parser,
+// for example for changing the delimiter.
+func DecodeMaps(r io.Reader, mods ...ReaderModifier) iter.Seq2[map[string]string, error] {
+	return readMaps(records(r, mods))
+}
+
+// Turns an iterator over entries into an iterator over maps,
+// using the first entry as the header.
+func readMaps(r iter.Seq2[record, error]) iter.Seq2[map[string]string, error] {
+	return func(yield func(map[string]string, error) bool) {
+		var cols []string
+		for e, err := range r {
+			if err != nil {
+				if !yield(nil, wrapReadError(err)) {
+					return
+				}
+				continue
+			}
+			if cols == nil {
+				cols = e.fields
+				continue
+			}
+			if len(e.fields) != len(cols) {
+				err := &DecodeError{Line: e.line, Column: -1, Err: fmt.Errorf(
+					"expected %v columns, got %v", len(cols), len(e.fields))}
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			m := make(map[string]string, len(cols))
+			for i, col := range cols {
+				m[col] = e.fields[i]
+			}
+			if !yield(m, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Turns an iterator over entries into an iterator over T.
+func read[T any](r iter.Seq2[record, error], header bool) iter.Seq2[T, error] {
 	return func(yield func(T, error) bool) {
 		var zero T
-		var m map[int][]setter
-		first := true
-		for line, err := range r {
+		var d *decoder[T]
+		for e, err := range r {
 			if err != nil {
-				if !yield(zero, err) {
+				if !yield(zero, wrapReadError(err)) {
 					return
 				}
 				continue
 			}
-			if first {
-				first = false
-				if header {
-					m, err = matchColToField(reflect.TypeFor[T](), line)
-				} else {
-					m, err = matchColToFieldNoHeader(reflect.TypeFor[T]())
-				}
+			if d == nil {
+				d, err = newDecoder[T](e, header)
 				if err != nil {
 					yield(zero, err)
 					return
@@ -138,313 +311,496 @@ func read[T any](r iter.Seq2[[]string, error], header bool) iter.Seq2[T, error]
 					continue
 				}
 			}
-			var t T
-			if err := populateStruct(&t, line, m); err != nil {
-				if !yield(zero, err) {
-					return
-				}
-			}
-			if !yield(t, nil) {
+			if !yield(d.decode(e)) {
 				return
 			}
 		}
 	}
 }
 
+// Decodes entries into instances of T.
+type decoder[T any] struct {
+	setters []colSetters // Setters by column order
+	cols    []string     // Header line, if any
+}
+
+// Returns a decoder for the entries that follow the given first entry.
+// If header is true, the first entry is used for matching columns to fields.
+func newDecoder[T any](first record, header bool) (*decoder[T], error) {
+	var m map[int][]setter
+	var err error
+	d := &decoder[T]{}
+	if header {
+		m, err = matchColToField(reflect.TypeFor[T](), first.fields)
+		d.cols = first.fields
+	} else {
+		m, err = matchColToFieldNoHeader(reflect.TypeFor[T](), len(first.fields))
+	}
+	if err != nil {
+		return nil, &DecodeError{Line: first.line, Column: -1, Err: err}
+	}
+	d.setters = sortSetters(m)
+	return d, nil
+}
+
+// Decodes a single entry.
+func (d *decoder[T]) decode(e record) (T, error) {
+	var t T
+	if err := populateStruct(&t, e.fields, d.setters); err != nil {
+		err.Line = e.line
+		if err.Column < len(d.cols) {
+			err.Header = d.cols[err.Column]
+		}
+		var zero T
+		return zero, err
+	}
+	return t, nil
+}
+
+// Converts CSV parse errors to decode errors.
+func wrapReadError(err error) error {
+	if perr, ok := err.(*csv.ParseError); ok {
+		return &DecodeError{Line: perr.Line, Column: -1, Err: perr}
+	}
+	return err
+}
+
 // Creates a map from column number to setter functions that
 // should run on that column's value, based on the type's metadata.
 func matchColToField(t reflect.Type, cols []string) (map[int][]setter, error) {
-	if t.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("expected struct, got %v", t)
-	}
-	cs := map[string][]setter{}
-	ci := map[string][]setter{}
-	ii := map[string][]setter{}
-
-	var found []bool
-	csf := map[string][]int{}
-	cif := map[string][]int{}
-	iif := map[string][]int{}
-
-	for i := range t.NumField() {
-		f := t.Field(i)
-		if !f.IsExported() {
-			found = append(found, true)
+	fs, err := structFields(t)
+	if err != nil {
+		return nil, err
+	}
+	m := map[int][]setter{}
+	for _, f := range fs {
+		if f.rest {
 			continue
 		}
-		name, m, mi := strings.ToLower(f.Name), ci, cif
-		allowEmpty, optional, parseFunc := false, false, false
-		parts := strings.Split(f.Tag.Get("csvx"), ",")
-		if tag := parts[0]; tag != "" {
-			if tag == "-" {
-				continue
-			}
-			name, m, mi = tag, cs, csf
-			if numeric(tag) {
-				m, mi = ii, iif
-			}
+		o, err := f.decodeOptions()
+		if err != nil {
+			return nil, err
 		}
-		mi[name] = append(mi[name], i)
-		for _, p := range parts[1:] {
-			if p == "allowempty" {
-				allowEmpty = true
-				continue
-			}
-			if p == "optional" {
-				optional = true
-				continue
-			}
-			method, ok := t.MethodByName(p)
-			if !ok {
-				return nil, fmt.Errorf("method not found: %v", p)
-			}
-			if !isParseFunc(method.Type, f.Type) {
-				return nil, fmt.Errorf("not a valid parse function: %v %v", p, method.Type)
-			}
-			v := method.Func
-			m[name] = append(m[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				out := v.Call([]reflect.Value{dst, reflect.ValueOf(src)})
-				if err := valueToError(out[1]); err != nil {
-					return err
+		var idx []int
+		switch {
+		case f.spanned:
+			idx = f.spanIndexes(len(cols))
+		case f.idx != -1:
+			idx = []int{f.idx}
+		default:
+			for i, col := range cols {
+				if f.matches(col) {
+					idx = append(idx, i)
 				}
-				dst.Field(i).Set(out[0])
-				return nil
-			})
-			parseFunc = true
+			}
 		}
-		found = append(found, optional)
-		if parseFunc {
-			continue
+		if len(idx) == 0 && !o.optional {
+			return nil, fmt.Errorf("field not matched in input: %v", f.name)
 		}
-		switch f.Type.Kind() {
-		case reflect.String:
-			m[name] = append(m[name], func(dst reflect.Value, src string) error {
-				dst.Field(i).SetString(src)
-				return nil
-			})
-		case reflect.Float32, reflect.Float64:
-			m[name] = append(m[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				x, err := strconv.ParseFloat(src, f.Type.Bits())
-				if err != nil {
-					return err
-				}
-				dst.Field(i).SetFloat(x)
-				return nil
-			})
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			m[name] = append(m[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				x, err := strconv.ParseInt(src, 0, f.Type.Bits())
-				if err != nil {
-					return err
-				}
-				dst.Field(i).SetInt(x)
-				return nil
-			})
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			m[name] = append(m[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				x, err := strconv.ParseUint(src, 0, f.Type.Bits())
-				if err != nil {
-					return err
-				}
-				dst.Field(i).SetUint(x)
-				return nil
-			})
-		case reflect.Bool:
-			m[name] = append(m[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				x, err := strconv.ParseBool(src)
-				if err != nil {
-					return err
-				}
-				dst.Field(i).SetBool(x)
-				return nil
-			})
+		if len(idx) > 1 && f.unique() {
+			var names []string
+			for _, i := range idx {
+				names = append(names, cols[i])
+			}
+			return nil, fmt.Errorf("field %v matches several columns: %q",
+				f.name, names)
 		}
+		if err := f.addSetters(m, idx, o); err != nil {
+			return nil, err
+		}
+	}
+	addRestSetters(m, fs, len(cols), func(i int) string { return cols[i] })
+	return m, nil
+}
+
+// Creates a map from column number to setter functions that
+// should run on that column's value, based on the type's metadata.
+// Ncols is the number of columns in the input.
+func matchColToFieldNoHeader(t reflect.Type, ncols int) (map[int][]setter, error) {
+	fs, err := structFields(t)
+	if err != nil {
+		return nil, err
 	}
 	m := map[int][]setter{}
-	for i, col := range cols {
-		m[i] = append(m[i], cs[col]...)
-		for _, x := range csf[col] {
-			found[x] = true
+	cur := 0
+	for _, f := range fs {
+		if f.rest {
+			continue
+		}
+		if f.named {
+			return nil, fmt.Errorf(
+				"field %q has a string tag name, which is not allowed in no-header mode",
+				f.name)
 		}
-		lower := strings.ToLower(col)
-		m[i] = append(m[i], ci[lower]...)
-		for _, x := range cif[lower] {
-			found[x] = true
+		o, err := f.decodeOptions()
+		if err != nil {
+			return nil, err
+		}
+		var idx []int
+		switch {
+		case f.spanned:
+			idx = f.spanIndexes(ncols)
+		case f.idx != -1:
+			idx = []int{f.idx}
+		default:
+			idx = []int{cur}
+			cur++
+		}
+		if err := f.addSetters(m, idx, o); err != nil {
+			return nil, err
 		}
 	}
-	for i, s := range ii {
-		n, _ := strconv.Atoi(i) // Not expecting error.
-		m[n] = append(m[n], s...)
-		for _, x := range iif[i] {
-			found[x] = true
+	addRestSetters(m, fs, ncols, strconv.Itoa)
+	return m, nil
+}
+
+// Adds setters for the rest fields among fs, on the columns that have no
+// setters. Key returns the map key for the column at the given index.
+func addRestSetters(m map[int][]setter, fs []field, ncols int,
+	key func(int) string) {
+	var unmatched []int
+	for i := range ncols {
+		if len(m[i]) == 0 {
+			unmatched = append(unmatched, i)
 		}
 	}
-	for i := range found {
-		if !found[i] {
-			return nil, fmt.Errorf("field not matched in input: %v",
-				t.Field(i).Name)
+	for _, f := range fs {
+		if !f.rest {
+			continue
+		}
+		for _, i := range unmatched {
+			m[i] = append(m[i], f.restSetter(key(i)))
 		}
 	}
-	return m, nil
 }
 
-// Creates a map from column number to setter functions that
-// should run on that column's value, based on the type's metadata.
-func matchColToFieldNoHeader(t reflect.Type) (map[int][]setter, error) {
-	if t.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("expected struct, got %v", t)
+// Returns a function that adds a value to this map field under the given
+// key.
+func (f field) restSetter(key string) setter {
+	k := reflect.ValueOf(key)
+	return func(dst reflect.Value, src string) error {
+		_, v := f.settable(dst)
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(restType))
+		}
+		v.SetMapIndex(k, reflect.ValueOf(src))
+		return nil
 	}
+}
 
-	ii := map[string][]setter{} // TODO(amit): Change to int keys.
-	cur := 0
+// Adds this field's setters to the columns at the given indexes.
+func (f field) addSetters(m map[int][]setter, idx []int, o decodeOptions) error {
+	if f.gathers() {
+		for k, i := range idx {
+			s, err := f.gatherSetter(k, len(idx), o)
+			if err != nil {
+				return err
+			}
+			m[i] = append(m[i], f.withError(o.withDefault(s)))
+		}
+		return nil
+	}
+	s, err := f.setter(o)
+	if err != nil {
+		return err
+	}
+	s = f.withError(o.withDefault(s))
+	for _, i := range idx {
+		m[i] = append(m[i], s)
+	}
+	return nil
+}
 
-	for i := range t.NumField() {
-		f := t.Field(i)
-		if !f.IsExported() {
-			continue
+// Wraps s such that its errors are returned as decode errors with this
+// field's name.
+func (f field) withError(s setter) setter {
+	return func(dst reflect.Value, src string) error {
+		if err := s(dst, src); err != nil {
+			return &DecodeError{Column: -1, Field: f.name, Value: src, Err: err}
 		}
-		name := fmt.Sprint(cur)
-		allowEmpty, parseFunc := false, false
-		parts := strings.Split(f.Tag.Get("csvx"), ",")
-		if tag := parts[0]; tag != "" {
-			if tag == "-" {
-				continue
-			}
-			if !numeric(tag) {
-				return nil, fmt.Errorf(
-					"field %q has a string tag name, which is not allowed in no-header mode",
-					f.Name)
-			}
-			name = tag
-		} else {
-			cur++
+		return nil
+	}
+}
+
+// Wraps s such that empty input is replaced with the default value,
+// if one was given.
+func (o decodeOptions) withDefault(s setter) setter {
+	if !o.hasDflt {
+		return s
+	}
+	return func(dst reflect.Value, src string) error {
+		if src == "" {
+			src = o.dflt
 		}
-		for _, p := range parts[1:] {
-			if p == "allowempty" {
-				allowEmpty = true
-				continue
+		return s(dst, src)
+	}
+}
+
+// Decoding options of a field, parsed from its tag modifiers.
+type decodeOptions struct {
+	allowEmpty bool   // Skip empty values
+	optional   bool   // Field may be missing from the input
+	sep        string // Separator of slice elements within a value
+	layout     string // Layout for parsing time values
+	dflt       string // Value to use instead of empty input
+	hasDflt    bool   // Whether dflt should be used
+
+	// Parsing method, if any.
+	parse func(parent reflect.Value, src string) (reflect.Value, error)
+}
+
+// Returns the decoding options of this field.
+func (f field) decodeOptions() (decodeOptions, error) {
+	var o decodeOptions
+	for _, p := range f.mods {
+		switch {
+		case p == "allowempty":
+			o.allowEmpty = true
+		case p == "optional":
+			o.optional = true
+		case strings.HasPrefix(p, "sep="):
+			o.sep = p[len("sep="):]
+			if o.sep == "" {
+				return o, fmt.Errorf("empty separator for field %v", f.name)
+			}
+			if f.sf.Type.Kind() != reflect.Slice {
+				return o, fmt.Errorf("separator for non-slice field %v: %v",
+					f.name, f.sf.Type)
 			}
-			method, ok := t.MethodByName(p)
+		case strings.HasPrefix(p, "layout="):
+			o.layout = p[len("layout="):]
+		case strings.HasPrefix(p, "default="):
+			o.dflt, o.hasDflt = p[len("default="):], true
+		case !isMethodMod(p): // Used for encoding.
+		default:
+			method, ok := f.parent.MethodByName(p)
 			if !ok {
-				return nil, fmt.Errorf("method not found: %v", p)
+				return o, fmt.Errorf("method not found: %v", p)
 			}
-			if !isParseFunc(method.Type, f.Type) {
-				return nil, fmt.Errorf("not a valid parse function: %v %v", p, method.Type)
+			if !isParseFunc(method.Type, f.sf.Type) {
+				return o, fmt.Errorf("not a valid parse function: %v %v",
+					p, method.Type)
 			}
 			v := method.Func
-			ii[name] = append(ii[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				out := v.Call([]reflect.Value{dst, reflect.ValueOf(src)})
-				if err := valueToError(out[1]); err != nil {
-					return err
-				}
-				dst.Field(i).Set(out[0])
-				return nil
-			})
-			parseFunc = true
-		}
-		if parseFunc {
-			continue
+			o.parse = func(parent reflect.Value, src string) (reflect.Value, error) {
+				out := v.Call([]reflect.Value{parent, reflect.ValueOf(src)})
+				return out[0], valueToError(out[1])
+			}
 		}
-		switch f.Type.Kind() {
-		case reflect.String:
-			ii[name] = append(ii[name], func(dst reflect.Value, src string) error {
-				dst.Field(i).SetString(src)
-				return nil
-			})
-		case reflect.Float32, reflect.Float64:
-			ii[name] = append(ii[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				x, err := strconv.ParseFloat(src, f.Type.Bits())
-				if err != nil {
-					return err
-				}
-				dst.Field(i).SetFloat(x)
-				return nil
-			})
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			ii[name] = append(ii[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				x, err := strconv.ParseInt(src, 0, f.Type.Bits())
-				if err != nil {
-					return err
-				}
-				dst.Field(i).SetInt(x)
+	}
+	// Pointer fields remain nil on empty input.
+	if f.sf.Type.Kind() == reflect.Pointer || f.inPtr {
+		o.allowEmpty = true
+	}
+	if o.layout == "" {
+		o.layout = time.RFC3339
+	}
+	return o, nil
+}
+
+// Returns a function that parses a value and sets this field in a root
+// struct.
+func (f field) setter(o decodeOptions) (setter, error) {
+	if o.parse != nil {
+		return func(dst reflect.Value, src string) error {
+			if o.allowEmpty && src == "" {
 				return nil
-			})
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			ii[name] = append(ii[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				x, err := strconv.ParseUint(src, 0, f.Type.Bits())
-				if err != nil {
-					return err
-				}
-				dst.Field(i).SetUint(x)
+			}
+			parent, v := f.settable(dst)
+			x, err := o.parse(parent, src)
+			if err != nil {
+				return err
+			}
+			v.Set(x)
+			return nil
+		}, nil
+	}
+
+	if o.sep != "" {
+		st := f.sf.Type
+		basic := parseBasic(st.Elem(), o.layout)
+		if basic == nil {
+			return nil, fmt.Errorf("unsupported slice type for field %v: %v",
+				f.name, st)
+		}
+		return func(dst reflect.Value, src string) error {
+			if src == "" { // Leave as nil.
 				return nil
-			})
-		case reflect.Bool:
-			ii[name] = append(ii[name], func(dst reflect.Value, src string) error {
-				if allowEmpty && src == "" {
-					return nil
-				}
-				x, err := strconv.ParseBool(src)
-				if err != nil {
+			}
+			parts := strings.Split(src, o.sep)
+			x := reflect.MakeSlice(st, len(parts), len(parts))
+			for i, p := range parts {
+				if err := basic(x.Index(i), p); err != nil {
 					return err
 				}
-				dst.Field(i).SetBool(x)
-				return nil
-			})
+			}
+			_, v := f.settable(dst)
+			v.Set(x)
+			return nil
+		}, nil
+	}
+
+	t := f.sf.Type
+	ptr := t.Kind() == reflect.Pointer
+	if ptr {
+		t = t.Elem()
+	}
+	basic := parseBasic(t, o.layout)
+	if basic == nil { // Unsupported type, ignore.
+		return func(dst reflect.Value, src string) error {
+			return nil
+		}, nil
+	}
+	return func(dst reflect.Value, src string) error {
+		if o.allowEmpty && src == "" {
+			return nil
 		}
+		_, v := f.settable(dst)
+		if ptr {
+			x := reflect.New(t)
+			if err := basic(x.Elem(), src); err != nil {
+				return err
+			}
+			v.Set(x)
+			return nil
+		}
+		return basic(v, src)
+	}, nil
+}
+
+// Returns a function that parses a value and sets it as the k'th element
+// of this slice field, out of n gathered columns.
+func (f field) gatherSetter(k, n int, o decodeOptions) (setter, error) {
+	st := f.sf.Type
+	basic := parseBasic(st.Elem(), o.layout)
+	if basic == nil {
+		return nil, fmt.Errorf("unsupported slice type for field %v: %v",
+			f.name, st)
 	}
-	m := map[int][]setter{}
-	for i, s := range ii {
-		n, _ := strconv.Atoi(i) // Not expecting error.
+	return func(dst reflect.Value, src string) error {
+		if o.allowEmpty && src == "" {
+			return nil
+		}
+		_, v := f.settable(dst)
+		if v.Len() != n {
+			v.Set(reflect.MakeSlice(st, n, n))
+		}
+		return basic(v.Index(k), src)
+	}, nil
+}
 
-		m[n] = append(m[n], s...)
+// Returns a function that parses a string into a value of type t,
+// or nil if t is not supported. Layout is used for parsing time values.
+func parseBasic(t reflect.Type, layout string) func(dst reflect.Value, src string) error {
+	switch {
+	case t == timeType:
+		return func(dst reflect.Value, src string) error {
+			x, err := time.Parse(layout, src)
+			if err != nil {
+				return err
+			}
+			dst.Set(reflect.ValueOf(x))
+			return nil
+		}
+	case t == durationType:
+		return func(dst reflect.Value, src string) error {
+			x, err := time.ParseDuration(src)
+			if err != nil {
+				return err
+			}
+			dst.SetInt(int64(x))
+			return nil
+		}
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return func(dst reflect.Value, src string) error {
+			return dst.Addr().Interface().(encoding.TextUnmarshaler).
+				UnmarshalText([]byte(src))
+		}
 	}
-	return m, nil
+	switch t.Kind() {
+	case reflect.String:
+		return func(dst reflect.Value, src string) error {
+			dst.SetString(src)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(dst reflect.Value, src string) error {
+			x, err := strconv.ParseFloat(src, t.Bits())
+			if err != nil {
+				return err
+			}
+			dst.SetFloat(x)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(dst reflect.Value, src string) error {
+			x, err := strconv.ParseInt(src, 0, t.Bits())
+			if err != nil {
+				return err
+			}
+			dst.SetInt(x)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(dst reflect.Value, src string) error {
+			x, err := strconv.ParseUint(src, 0, t.Bits())
+			if err != nil {
+				return err
+			}
+			dst.SetUint(x)
+			return nil
+		}
+	case reflect.Bool:
+		return func(dst reflect.Value, src string) error {
+			x, err := strconv.ParseBool(src)
+			if err != nil {
+				return err
+			}
+			dst.SetBool(x)
+			return nil
+		}
+	}
+	return nil
 }
 
-// Populates a's fields given the input values and setter-map.
-func populateStruct(a any, vals []string, setters map[int][]setter) error {
+// Populates a's fields given the input values and setters.
+func populateStruct(a any, vals []string, setters []colSetters) *DecodeError {
 	v := reflect.ValueOf(a).Elem()
-	for i, ss := range setters {
+	for _, cs := range setters {
+		i := cs.col
 		if i >= len(vals) {
-			return fmt.Errorf("cannot read column #%v, input has %v columns: %q",
-				i, len(vals), vals)
+			return &DecodeError{Column: i, Err: fmt.Errorf(
+				"cannot read column #%v, input has %v columns", i, len(vals))}
 		}
-		for _, s := range ss {
+		for _, s := range cs.setters {
 			if err := s(v, vals[i]); err != nil {
-				return err
+				derr := err.(*DecodeError) // Setters return decode errors.
+				derr.Column = i
+				return derr
 			}
 		}
 	}
 	return nil
 }
 
+// The setters of a single column.
+type colSetters struct {
+	col     int
+	setters []setter
+}
+
+// Returns the setters in the given map, ordered by column.
+func sortSetters(m map[int][]setter) []colSetters {
+	var result []colSetters
+	for i, s := range m {
+		result = append(result, colSetters{i, s})
+	}
+	slices.SortFunc(result, func(a, b colSetters) int {
+		return a.col - b.col
+	})
+	return result
+}
+
 // A function that parses a string and sets the given value accordingly.
 type setter func(dst reflect.Value, src string) error
 
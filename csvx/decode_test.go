@@ -3,9 +3,15 @@ package csvx
 import (
 	"bytes"
 	"fmt"
+	"math/big"
+	"net/netip"
 	"reflect"
+	"slices"
 	"strconv"
 	"testing"
+	"time"
+
+	"github.com/fluhus/gostuff/iterx"
 )
 
 func TestDecodeReader_basic(t *testing.T) {
@@ -231,3 +237,541 @@ type badParserItem4 struct {
 func (badParserItem4) Parse(string) (string, error) {
 	return "", nil
 }
+
+func TestDecodeReader_nested(t *testing.T) {
+	tests := []testCase[nestedItem]{
+		{"name,addr.city,address.zip,inty,floaty\nbla,paris,123,5,6",
+			nestedItem{Name: "bla", Addr: nestedAddress{City: "paris"},
+				Address: &nestedZip{Zip: 123}, embeddedItem: embeddedItem{
+					Inty: 5}, Floaty: ptr(6.0)}, false},
+		{"Name,ADDR.CITY,address.zip,inty,floaty\nbla,paris,,5,",
+			nestedItem{Name: "bla", Addr: nestedAddress{City: "paris"},
+				embeddedItem: embeddedItem{Inty: 5}}, false},
+
+		{"name,addr.city,Address.zip,inty,floaty\nbla,paris,123,5,6",
+			nestedItem{}, true},
+		{"name,city,address.zip,inty,floaty\nbla,paris,123,5,6",
+			nestedItem{}, true},
+		{"name,addr.city,address.zip,floaty\nbla,paris,123,6",
+			nestedItem{}, true},
+		{"name,addr.city,address.zip,inty,floaty\nbla,paris,123,5,a",
+			nestedItem{}, true},
+	}
+	testGeneric(t, true, tests)
+}
+
+func TestDecodeReader_nestedNoHeader(t *testing.T) {
+	tests := []testCase[nestedNoHeaderItem]{
+		{"bla,paris,5,", nestedNoHeaderItem{Name: "bla",
+			Addr: &nestedAddress{City: "paris"}, embeddedItem: embeddedItem{
+				Inty: 5}}, false},
+		{"bla,,5,6", nestedNoHeaderItem{Name: "bla",
+			embeddedItem: embeddedItem{Inty: 5}, Floaty: ptr(6.0)}, false},
+	}
+	testGeneric(t, false, tests)
+}
+
+func TestEncodeWriter_nested(t *testing.T) {
+	input := []nestedItem{
+		{Name: "bla", Addr: nestedAddress{City: "paris"},
+			Address: &nestedZip{Zip: 123}, embeddedItem: embeddedItem{
+				Inty: 5}, Floaty: ptr(6.0)},
+		{Name: "blu", embeddedItem: embeddedItem{Inty: 7}},
+	}
+	want := "Name,Addr.City,address.zip,Inty,Floaty\n" +
+		"bla,paris,123,5,6\n" +
+		"blu,,,7,\n"
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values(input)); err != nil {
+		t.Fatalf("EncodeWriter(%v) failed: %v", input, err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("EncodeWriter(%v)=%q, want %q", input, got, want)
+	}
+	got, err := iterx.CollectErr(DecodeReaderHeader[nestedItem](buf))
+	if err != nil {
+		t.Fatalf("DecodeReaderHeader(...) failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Fatalf("DecodeReaderHeader(...)=%v, want %v", got, input)
+	}
+}
+
+type nestedItem struct {
+	Name    string
+	Addr    nestedAddress
+	Address *nestedZip `csvx:"address"`
+	embeddedItem
+	Floaty *float64
+}
+
+type nestedAddress struct {
+	City string
+}
+
+type nestedZip struct {
+	Zip int `csvx:"zip"`
+}
+
+type embeddedItem struct {
+	Inty int
+}
+
+type nestedNoHeaderItem struct {
+	Name string
+	Addr *nestedAddress
+	embeddedItem
+	Floaty *float64
+}
+
+func TestDecodeReader_recursive(t *testing.T) {
+	tests := []testCase[recursiveItem]{
+		{"name\nbla", recursiveItem{}, true},
+	}
+	testGeneric(t, true, tests)
+	testGeneric(t, false, tests)
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values([]recursiveItem{{}})); err == nil {
+		t.Fatalf("EncodeWriter(recursiveItem) succeeded, want error")
+	}
+
+	// The same type in sibling fields is not recursive.
+	type twoAddresses struct {
+		Home, Work nestedAddress
+	}
+	got, err := iterx.CollectErr(DecodeReaderHeader[twoAddresses](
+		bytes.NewBufferString("home.city,work.city\na,b\n")))
+	want := []twoAddresses{{nestedAddress{"a"}, nestedAddress{"b"}}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("DecodeReaderHeader(...)=%v,%v, want %v", got, err, want)
+	}
+}
+
+type recursiveItem struct {
+	Name string
+	Next *recursiveItem `csvx:",optional"`
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
+
+func TestDecodeReader_slices(t *testing.T) {
+	tests := []testCase[sliceItem]{
+		{"name,ints,sample_1,x,sample_2\nbla,1;2;3,4,5,6",
+			sliceItem{Name: "bla", Ints: []int{1, 2, 3},
+				Samples: []float64{4, 6}, Rest: []string{"5", "6"}}, false},
+		{"name,ints,sample_1\nbla,,4",
+			sliceItem{Name: "bla", Samples: []float64{4}}, false},
+
+		{"name,ints,sample_1,x,sample_2\nbla,1;a;3,4,5,6", sliceItem{}, true},
+		{"name,ints,sample_1,x,sample_2\nbla,1;2;3,4,5,a", sliceItem{}, true},
+		{"name,ints\nbla,1;2;3", sliceItem{}, true},
+	}
+	testGeneric(t, true, tests)
+}
+
+func TestDecodeReader_slicesNoHeader(t *testing.T) {
+	tests := []testCase[sliceNoHeaderItem]{
+		{"bla,1;2,3,4,5", sliceNoHeaderItem{Name: "bla", Ints: []int{1, 2},
+			Values: []int{3, 4, 5}}, false},
+		{"bla,1,3", sliceNoHeaderItem{Name: "bla", Ints: []int{1},
+			Values: []int{3}}, false},
+		{"bla,1", sliceNoHeaderItem{Name: "bla", Ints: []int{1}}, false},
+	}
+	testGeneric(t, false, tests)
+}
+
+func TestDecodeReader_badSlices(t *testing.T) {
+	testGeneric(t, true, []testCase[badSliceItem1]{{"a\n1", badSliceItem1{}, true}})
+	testGeneric(t, true, []testCase[badSliceItem2]{{"a\n1", badSliceItem2{}, true}})
+	testGeneric(t, true, []testCase[badSliceItem3]{{"a_1\n1", badSliceItem3{}, true}})
+}
+
+func TestEncodeWriter_slices(t *testing.T) {
+	type item struct {
+		Name string
+		Ints []int `csvx:",sep=;"`
+	}
+	input := []item{{"a", []int{1, 2, 3}}, {"b", nil}}
+	want := "Name,Ints\na,1;2;3\nb,\n"
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values(input)); err != nil {
+		t.Fatalf("EncodeWriter(%v) failed: %v", input, err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("EncodeWriter(%v)=%q, want %q", input, got, want)
+	}
+	if err := EncodeWriter(buf, slices.Values([]sliceItem{{}})); err == nil {
+		t.Fatalf("EncodeWriter(sliceItem) succeeded, want error")
+	}
+}
+
+func TestDecodeReader_commaSep(t *testing.T) {
+	type item struct {
+		Name string `csvx:"the,,name"`
+		Ints []int  `csvx:",sep=,,,optional"`
+	}
+	input := []item{{"a", []int{1, 2, 3}}, {"b", nil}}
+	want := "the,name\tInts\na\t1,2,3\nb\t\n"
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values(input), TSV); err != nil {
+		t.Fatalf("EncodeWriter(%v) failed: %v", input, err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("EncodeWriter(%v)=%q, want %q", input, got, want)
+	}
+	got, err := iterx.CollectErr(DecodeReaderHeader[item](buf, TSV))
+	if err != nil {
+		t.Fatalf("DecodeReaderHeader(%q) failed: %v", want, err)
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Fatalf("DecodeReaderHeader(%q)=%v, want %v", want, got, input)
+	}
+}
+
+func TestSplitTag(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", []string{""}},
+		{"a", []string{"a"}},
+		{"a,b,", []string{"a", "b", ""}},
+		{",sep=,,", []string{"", "sep=,"}},
+		{",sep=,,,optional", []string{"", "sep=,", "optional"}},
+		{"a,,b,layout=Jan 2,, 2006", []string{"a,b", "layout=Jan 2, 2006"}},
+	}
+	for _, test := range tests {
+		if got := splitTag(test.input); !slices.Equal(got, test.want) {
+			t.Errorf("splitTag(%q)=%q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+type sliceItem struct {
+	Name    string
+	Ints    []int     `csvx:",sep=;"`
+	Samples []float64 `csvx:"sample_*"`
+	Rest    []string  `csvx:"3:,optional"`
+}
+
+type sliceNoHeaderItem struct {
+	Name   string
+	Ints   []int `csvx:",sep=;"`
+	Values []int `csvx:"2:"`
+}
+
+type badSliceItem1 struct {
+	A int `csvx:",sep=;"`
+}
+
+type badSliceItem2 struct {
+	A int `csvx:"0:"`
+}
+
+type badSliceItem3 struct {
+	A []struct{} `csvx:"a_*"`
+}
+
+func TestDecodeReader_special(t *testing.T) {
+	tests := []testCase[specialItem]{
+		{"when,date,took,addr,count\n" +
+			"2024-01-02T03:04:05Z,2024-01-02,1m30s,1.2.3.4,5",
+			specialItem{
+				When:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Date:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+				Took:  90 * time.Second,
+				Addr:  netip.MustParseAddr("1.2.3.4"),
+				Count: 5,
+			}, false},
+		{"when,date,took,addr,count\n" +
+			"2024-01-02T03:04:05Z,2024-01-02,,::1,",
+			specialItem{
+				When:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Date:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+				Took:  time.Second,
+				Addr:  netip.MustParseAddr("::1"),
+				Count: 42,
+			}, false},
+
+		{"when,date,took,addr,count\n" +
+			"2024-01-02,2024-01-02,1s,1.2.3.4,5", specialItem{}, true},
+		{"when,date,took,addr,count\n" +
+			"2024-01-02T03:04:05Z,2024-01-02,1,1.2.3.4,5", specialItem{}, true},
+		{"when,date,took,addr,count\n" +
+			"2024-01-02T03:04:05Z,2024-01-02,1s,1.2.3,5", specialItem{}, true},
+	}
+	testGeneric(t, true, tests)
+}
+
+func TestEncodeWriter_special(t *testing.T) {
+	input := []specialItem{{
+		When:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Date:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Took:  90 * time.Second,
+		Addr:  netip.MustParseAddr("1.2.3.4"),
+		Count: 5,
+	}}
+	want := "When,Date,Took,Addr,Count\n" +
+		"2024-01-02T03:04:05Z,2024-01-02,1m30s,1.2.3.4,5\n"
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values(input)); err != nil {
+		t.Fatalf("EncodeWriter(%v) failed: %v", input, err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("EncodeWriter(%v)=%q, want %q", input, got, want)
+	}
+}
+
+func TestDecodeReader_commaLayout(t *testing.T) {
+	type item struct {
+		Day  time.Time `csvx:",layout=Jan 2,, 2006"`
+		When time.Time `csvx:",layout=Mon,, 02 Jan 2006 15:04:05 MST"`
+	}
+	tests := []testCase[item]{
+		{"day,when\n\"Jan 5, 2024\",\"Fri, 05 Jan 2024 03:04:05 UTC\"",
+			item{time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 5, 3, 4, 5, 0, time.UTC)}, false},
+		{"day,when\n2024-01-05,\"Fri, 05 Jan 2024 03:04:05 UTC\"",
+			item{}, true},
+	}
+	testGeneric(t, true, tests)
+}
+
+func TestEncodeWriter_pointerMarshaler(t *testing.T) {
+	type item struct {
+		A *big.Int
+		B big.Int
+		C []big.Int `csvx:",sep=;"`
+	}
+	input := []item{{big.NewInt(123), *big.NewInt(-45),
+		[]big.Int{*big.NewInt(6), *big.NewInt(7)}}}
+	want := "A,B,C\n123,-45,6;7\n"
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values(input)); err != nil {
+		t.Fatalf("EncodeWriter(%v) failed: %v", input, err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("EncodeWriter(%v)=%q, want %q", input, got, want)
+	}
+	got, err := iterx.CollectErr(DecodeReaderHeader[item](buf))
+	if err != nil {
+		t.Fatalf("DecodeReaderHeader(...) failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Fatalf("DecodeReaderHeader(...)=%v, want %v", got, input)
+	}
+}
+
+type specialItem struct {
+	When  time.Time
+	Date  time.Time     `csvx:",layout=2006-01-02"`
+	Took  time.Duration `csvx:",default=1s"`
+	Addr  netip.Addr
+	Count int `csvx:",default=42"`
+}
+
+func TestDecodeReader_rest(t *testing.T) {
+	type item struct {
+		A    int
+		Rest map[string]string `csvx:",rest"`
+	}
+	input := "A,x,y\n1,a,b\n2,c,\n"
+	want := []item{
+		{1, map[string]string{"x": "a", "y": "b"}},
+		{2, map[string]string{"x": "c", "y": ""}},
+	}
+	got, err := iterx.CollectErr(DecodeReaderHeader[item](
+		bytes.NewBufferString(input)))
+	if err != nil {
+		t.Fatalf("DecodeReaderHeader(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DecodeReaderHeader(%q)=%v, want %v", input, got, want)
+	}
+}
+
+func TestDecodeReader_restNoHeader(t *testing.T) {
+	type item struct {
+		Rest map[string]string `csvx:",rest"`
+		A    int
+	}
+	input := "1,a,b\n"
+	want := []item{{map[string]string{"1": "a", "2": "b"}, 1}}
+	got, err := iterx.CollectErr(DecodeReader[item](bytes.NewBufferString(input)))
+	if err != nil {
+		t.Fatalf("DecodeReader(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DecodeReader(%q)=%v, want %v", input, got, want)
+	}
+}
+
+func TestDecodeReader_restEmpty(t *testing.T) {
+	type item struct {
+		A    int
+		Rest map[string]string `csvx:",rest"`
+	}
+	input := "A\n1\n"
+	want := []item{{1, nil}}
+	got, err := iterx.CollectErr(DecodeReaderHeader[item](
+		bytes.NewBufferString(input)))
+	if err != nil {
+		t.Fatalf("DecodeReaderHeader(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DecodeReaderHeader(%q)=%v, want %v", input, got, want)
+	}
+}
+
+func TestDecodeReader_badRest(t *testing.T) {
+	type named struct {
+		Rest map[string]string `csvx:"r,rest"`
+	}
+	type wrongType struct {
+		Rest map[string]int `csvx:",rest"`
+	}
+	input := "a\n1\n"
+	if _, err := iterx.CollectErr(DecodeReaderHeader[named](
+		bytes.NewBufferString(input))); err == nil {
+		t.Fatalf("DecodeReaderHeader[named](%q) succeeded, want error", input)
+	}
+	if _, err := iterx.CollectErr(DecodeReaderHeader[wrongType](
+		bytes.NewBufferString(input))); err == nil {
+		t.Fatalf("DecodeReaderHeader[wrongType](%q) succeeded, want error", input)
+	}
+}
+
+func TestEncodeWriter_rest(t *testing.T) {
+	type item struct {
+		A    int
+		Rest map[string]string `csvx:",rest"`
+	}
+	input := []item{
+		{1, map[string]string{"y": "b", "x": "a"}},
+		{2, map[string]string{"x": "c"}},
+		{3, nil},
+	}
+	want := "A,x,y\n1,a,b\n2,c,\n3,,\n"
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values(input)); err != nil {
+		t.Fatalf("EncodeWriter(%v) failed: %v", input, err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("EncodeWriter(%v)=%q, want %q", input, got, want)
+	}
+	got, err := iterx.CollectErr(DecodeReaderHeader[item](buf))
+	if err != nil {
+		t.Fatalf("DecodeReaderHeader(...) failed: %v", err)
+	}
+	wantDecoded := []item{
+		{1, map[string]string{"x": "a", "y": "b"}},
+		{2, map[string]string{"x": "c", "y": ""}},
+		{3, map[string]string{"x": "", "y": ""}},
+	}
+	if !reflect.DeepEqual(got, wantDecoded) {
+		t.Fatalf("DecodeReaderHeader(...)=%v, want %v", got, wantDecoded)
+	}
+
+	buf.Reset()
+	if err := EncodeWriter(buf, slices.Values([]item{})); err != nil {
+		t.Fatalf("EncodeWriter([]) failed: %v", err)
+	}
+	if got := buf.String(); got != "A\n" {
+		t.Fatalf("EncodeWriter([])=%q, want %q", got, "A\n")
+	}
+
+	bad := [][]item{
+		{{1, nil}, {2, map[string]string{"x": "a"}}},
+		{{1, map[string]string{"A": "a"}}},
+	}
+	for _, input := range bad {
+		if err := EncodeWriter(buf, slices.Values(input)); err == nil {
+			t.Fatalf("EncodeWriter(%v) succeeded, want error", input)
+		}
+	}
+}
+
+func TestDecodeMaps(t *testing.T) {
+	input := "a,b\n1,2\n3\n4,5\n"
+	want := []map[string]string{{"a": "1", "b": "2"}, {"a": "4", "b": "5"}}
+	var got []map[string]string
+	nerr := 0
+	for m, err := range DecodeMaps(bytes.NewBufferString(input)) {
+		if err != nil {
+			nerr++
+			continue
+		}
+		got = append(got, m)
+	}
+	if !reflect.DeepEqual(got, want) || nerr != 1 {
+		t.Fatalf("DecodeMaps(%q)=%v,%v errors, want %v,1 error",
+			input, got, nerr, want)
+	}
+}
+
+func TestDecodeReader_aliases(t *testing.T) {
+	type item struct {
+		ID    string `csvx:"sample_id|SampleID|sample"`
+		Value int    `csvx:"/val(ue)?_?[0-9]*/"`
+	}
+	inputs := []string{
+		"sample_id,value\na,1\n",
+		"SampleID,val_2\na,1\n",
+		"other,sample,val\n,a,1\n",
+	}
+	want := []item{{"a", 1}}
+	for _, input := range inputs {
+		got, err := iterx.CollectErr(DecodeReaderHeader[item](
+			bytes.NewBufferString(input)))
+		if err != nil {
+			t.Fatalf("DecodeReaderHeader(%q) failed: %v", input, err)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("DecodeReaderHeader(%q)=%v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestDecodeReader_badAliases(t *testing.T) {
+	type item struct {
+		ID    string `csvx:"sample_id|SampleID"`
+		Value int    `csvx:"/val.*/"`
+	}
+	inputs := []string{
+		"sample_id,SampleID,value\na,b,1\n",
+		"sample_id,value,val2\na,1,2\n",
+		"sampleid,value\na,1\n",
+	}
+	for _, input := range inputs {
+		if _, err := iterx.CollectErr(DecodeReaderHeader[item](
+			bytes.NewBufferString(input))); err == nil {
+			t.Fatalf("DecodeReaderHeader(%q) succeeded, want error", input)
+		}
+	}
+	if _, err := iterx.CollectErr(DecodeReader[item](
+		bytes.NewBufferString("a,1\n"))); err == nil {
+		t.Fatalf("DecodeReader(...) succeeded, want error")
+	}
+	type badPattern struct {
+		A int `csvx:"/a(/"`
+	}
+	if _, err := iterx.CollectErr(DecodeReaderHeader[badPattern](
+		bytes.NewBufferString("a\n1\n"))); err == nil {
+		t.Fatalf("DecodeReaderHeader[badPattern](...) succeeded, want error")
+	}
+}
+
+func TestEncodeWriter_aliases(t *testing.T) {
+	type item struct {
+		ID string `csvx:"sample_id|SampleID"`
+	}
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values([]item{{"a"}})); err != nil {
+		t.Fatalf("EncodeWriter(...) failed: %v", err)
+	}
+	if got, want := buf.String(), "sample_id\na\n"; got != want {
+		t.Fatalf("EncodeWriter(...)=%q, want %q", got, want)
+	}
+}
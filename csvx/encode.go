@@ -0,0 +1,382 @@
+package csvx
+
+import (
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fluhus/gostuff/aio"
+)
+
+// EncodeFile writes the given instances of T to a file, with a header line.
+// The output can be read back with [DecodeFileHeader].
+//
+// Only the delimiter (Comma) of the modifiers is used,
+// so the same modifiers may be passed to the encoder and the decoder.
+func EncodeFile[T any](file string, items iter.Seq[T], mods ...ReaderModifier) error {
+	f, err := aio.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := EncodeWriter(f, items, mods...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// EncodeWriter writes the given instances of T to a writer,
+// with a header line.
+// The output can be read back with [DecodeReaderHeader].
+//
+// Only the delimiter (Comma) of the modifiers is used,
+// so the same modifiers may be passed to the encoder and the decoder.
+func EncodeWriter[T any](w io.Writer, items iter.Seq[T], mods ...ReaderModifier) error {
+	fs, rest, err := fieldFormatters(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+
+	r := csv.NewReader(nil)
+	for _, mod := range mods {
+		mod(r)
+	}
+	c := csv.NewWriter(w)
+	c.Comma = r.Comma
+
+	// The header is written with the first instance,
+	// which determines the rest columns.
+	var keys []string
+	header := func(v reflect.Value) error {
+		var line []string
+		for _, f := range fs {
+			line = append(line, f.name)
+		}
+		if rest != nil && v.IsValid() {
+			keys = rest.restKeys(v)
+			for _, k := range keys {
+				if slices.Contains(line, k) {
+					return fmt.Errorf("field %v has key %q, which is also "+
+						"a column of another field", rest.name, k)
+				}
+			}
+			line = append(line, keys...)
+		}
+		return c.Write(line)
+	}
+
+	first := true
+	var line []string
+	for t := range items {
+		v := reflect.ValueOf(t)
+		if first {
+			if err := header(v); err != nil {
+				return err
+			}
+			first = false
+		}
+		line = line[:0]
+		for _, f := range fs {
+			s, err := f.format(v)
+			if err != nil {
+				return err
+			}
+			line = append(line, s)
+		}
+		if rest != nil {
+			line, err = rest.appendRest(line, v, keys)
+			if err != nil {
+				return err
+			}
+		}
+		if err := c.Write(line); err != nil {
+			return err
+		}
+	}
+	if first {
+		if err := header(reflect.Value{}); err != nil {
+			return err
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
+
+// A column's name and a function that formats its value.
+type formatter struct {
+	name   string
+	idx    int // Column index from tag, or -1
+	format func(src reflect.Value) (string, error)
+}
+
+// Returns the column formatters of the given type, by field order,
+// and its rest field if it has one.
+func fieldFormatters(t reflect.Type) ([]formatter, *field, error) {
+	fs, err := structFields(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	var result []formatter
+	var rest *field
+	for _, f := range fs {
+		if f.rest {
+			if rest != nil {
+				return nil, nil, fmt.Errorf(
+					"cannot encode more than one rest field: %v, %v",
+					rest.name, f.name)
+			}
+			rest = &f
+			continue
+		}
+		format, err := f.formatter()
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, formatter{f.column(), f.idx, format})
+	}
+	result, err = placeIndexed(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, rest, nil
+}
+
+// Moves the formatters of index-tagged fields to their column indexes,
+// so that the output decodes back to the same values. The other fields
+// fill the remaining columns in order, and columns that are left over are
+// written empty.
+func placeIndexed(fs []formatter) ([]formatter, error) {
+	n := len(fs)
+	for _, f := range fs {
+		n = max(n, f.idx+1)
+	}
+	result := make([]formatter, n)
+	taken := make([]bool, n)
+	for _, f := range fs {
+		if f.idx == -1 {
+			continue
+		}
+		if taken[f.idx] {
+			return nil, fmt.Errorf("column %d is tagged on more than one field",
+				f.idx)
+		}
+		result[f.idx], taken[f.idx] = f, true
+	}
+	i := 0
+	for _, f := range fs {
+		if f.idx != -1 {
+			continue
+		}
+		for taken[i] {
+			i++
+		}
+		result[i], taken[i] = f, true
+	}
+	for i := range result {
+		if !taken[i] {
+			result[i] = formatter{idx: i, format: formatEmpty}
+		}
+	}
+	return result, nil
+}
+
+// Returns the sorted keys of this rest field's map in a root struct.
+func (f field) restKeys(src reflect.Value) []string {
+	_, x, ok := f.get(src)
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, x.Len())
+	for _, k := range x.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Appends the values of this rest field's map in a root struct,
+// by the given keys. Missing keys are appended as empty strings.
+func (f field) appendRest(line []string, src reflect.Value,
+	keys []string) ([]string, error) {
+	_, x, ok := f.get(src)
+	if !ok {
+		x = reflect.Zero(restType)
+	}
+	m := x.Interface().(map[string]string)
+	for k := range m {
+		if _, found := slices.BinarySearch(keys, k); !found {
+			return nil, fmt.Errorf("field %v has key %q, which is not in the "+
+				"header", f.name, k)
+		}
+	}
+	for _, k := range keys {
+		line = append(line, m[k])
+	}
+	return line, nil
+}
+
+// Formats a column that no field is written to.
+func formatEmpty(reflect.Value) (string, error) {
+	return "", nil
+}
+
+// Returns a function that formats this field's value in a root struct.
+// Nil pointers are formatted as empty strings.
+func (f field) formatter() (func(src reflect.Value) (string, error), error) {
+	for _, p := range f.mods {
+		mname, ok := strings.CutPrefix(p, "format=")
+		if !ok {
+			continue
+		}
+		method, ok := f.parent.MethodByName(mname)
+		if !ok {
+			return nil, fmt.Errorf("method not found: %v", mname)
+		}
+		if !isFormatFunc(method.Type, f.sf.Type) {
+			return nil, fmt.Errorf("not a valid format function: %v %v",
+				mname, method.Type)
+		}
+		v := method.Func
+		return func(src reflect.Value) (string, error) {
+			parent, x, ok := f.get(src)
+			if !ok {
+				return "", nil
+			}
+			return v.Call([]reflect.Value{parent, x})[0].String(), nil
+		}, nil
+	}
+
+	if f.gathers() {
+		return nil, fmt.Errorf("cannot encode field %v, which gathers columns",
+			f.name)
+	}
+	if f.re != nil {
+		return nil, fmt.Errorf(
+			"cannot encode field %v, which matches columns by a pattern", f.name)
+	}
+	layout := time.RFC3339
+	for _, p := range f.mods {
+		if l, ok := strings.CutPrefix(p, "layout="); ok {
+			layout = l
+		}
+	}
+	for _, p := range f.mods {
+		sep, ok := strings.CutPrefix(p, "sep=")
+		if !ok || f.sf.Type.Kind() != reflect.Slice {
+			continue
+		}
+		format := formatBasic(f.sf.Type.Elem(), layout)
+		if format == nil {
+			return nil, fmt.Errorf("unsupported slice type for field %v: %v",
+				f.name, f.sf.Type)
+		}
+		return func(src reflect.Value) (string, error) {
+			_, x, ok := f.get(src)
+			if !ok {
+				return "", nil
+			}
+			parts := make([]string, x.Len())
+			for i := range parts {
+				var err error
+				parts[i], err = format(x.Index(i))
+				if err != nil {
+					return "", err
+				}
+			}
+			return strings.Join(parts, sep), nil
+		}, nil
+	}
+
+	t := f.sf.Type
+	ptr := t.Kind() == reflect.Pointer
+	if ptr {
+		t = t.Elem()
+	}
+	format := formatBasic(t, layout)
+	if format == nil {
+		return nil, fmt.Errorf("unsupported type for field %v: %v",
+			f.name, f.sf.Type)
+	}
+	return func(src reflect.Value) (string, error) {
+		_, x, ok := f.get(src)
+		if !ok {
+			return "", nil
+		}
+		if ptr {
+			if x.IsNil() {
+				return "", nil
+			}
+			x = x.Elem()
+		}
+		return format(x)
+	}, nil
+}
+
+// Returns a function that formats a value of type t,
+// or nil if t is not supported. Layout is used for formatting time values.
+func formatBasic(t reflect.Type, layout string) func(src reflect.Value) (string, error) {
+	switch {
+	case t == timeType:
+		return func(src reflect.Value) (string, error) {
+			return src.Interface().(time.Time).Format(layout), nil
+		}
+	case t == durationType:
+		return func(src reflect.Value) (string, error) {
+			return time.Duration(src.Int()).String(), nil
+		}
+	case t.Implements(textMarshalerType):
+		return func(src reflect.Value) (string, error) {
+			b, err := src.Interface().(encoding.TextMarshaler).MarshalText()
+			return string(b), err
+		}
+	case reflect.PointerTo(t).Implements(textMarshalerType):
+		return func(src reflect.Value) (string, error) {
+			if !src.CanAddr() { // Copy to an addressable value.
+				p := reflect.New(t)
+				p.Elem().Set(src)
+				src = p.Elem()
+			}
+			b, err := src.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+			return string(b), err
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return func(src reflect.Value) (string, error) {
+			return src.String(), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(src reflect.Value) (string, error) {
+			return strconv.FormatFloat(src.Float(), 'g', -1, t.Bits()), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(src reflect.Value) (string, error) {
+			return strconv.FormatInt(src.Int(), 10), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(src reflect.Value) (string, error) {
+			return strconv.FormatUint(src.Uint(), 10), nil
+		}
+	case reflect.Bool:
+		return func(src reflect.Value) (string, error) {
+			return strconv.FormatBool(src.Bool()), nil
+		}
+	}
+	return nil
+}
+
+// Checks that t's type matches the requirements for formatting
+// a value of type src.
+func isFormatFunc(t, src reflect.Type) bool {
+	return t.Kind() == reflect.Func &&
+		t.NumIn() == 2 && t.NumOut() == 1 &&
+		src.AssignableTo(t.In(1)) &&
+		t.Out(0).Kind() == reflect.String
+}
@@ -0,0 +1,158 @@
+package csvx
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/gostuff/iterx"
+)
+
+func TestEncodeWriter(t *testing.T) {
+	input := []encodeItem{
+		{"alice", 30, 1.5, true, 3, "x", 7},
+		{"bob, jr.", -2, 1e-9, false, 0, "y", 8},
+	}
+	want := "Name,age,Score,Ok,Count,hex\n" +
+		"alice,30,1.5,true,3,0x7\n" +
+		"\"bob, jr.\",-2,1e-09,false,0,0x8\n"
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values(input)); err != nil {
+		t.Fatalf("EncodeWriter(%v) failed: %v", input, err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("EncodeWriter(%v)=%q, want %q", input, got, want)
+	}
+
+	got, err := iterx.CollectErr(DecodeReaderHeader[encodeItem](buf))
+	if err != nil {
+		t.Fatalf("DecodeReaderHeader(...) failed: %v", err)
+	}
+	for i := range input {
+		input[i].Skipped = ""
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Fatalf("DecodeReaderHeader(...)=%v, want %v", got, input)
+	}
+}
+
+func TestEncodeFile(t *testing.T) {
+	type item struct {
+		Name string
+		Age  int
+	}
+	input := []item{{"alice", 30}, {"bob", 25}}
+	for _, suffix := range []string{".tsv", ".tsv.gz"} {
+		t.Run(suffix, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "a"+suffix)
+			if err := EncodeFile(file, slices.Values(input), TSV); err != nil {
+				t.Fatalf("EncodeFile(%q) failed: %v", file, err)
+			}
+			got, err := iterx.CollectErr(DecodeFileHeader[item](file, TSV))
+			if err != nil {
+				t.Fatalf("DecodeFileHeader(%q) failed: %v", file, err)
+			}
+			if !slices.Equal(got, input) {
+				t.Fatalf("DecodeFileHeader(%q)=%v, want %v", file, got, input)
+			}
+		})
+	}
+}
+
+func TestEncodeWriter_tsv(t *testing.T) {
+	type item struct {
+		A string
+		B int `csvx:"bee"`
+	}
+	input := []item{{"a", 1}, {"b", 2}}
+	want := "A\tbee\na\t1\nb\t2\n"
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values(input), TSV); err != nil {
+		t.Fatalf("EncodeWriter(%v) failed: %v", input, err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("EncodeWriter(%v)=%q, want %q", input, got, want)
+	}
+}
+
+func TestEncodeWriter_indexes(t *testing.T) {
+	type item struct {
+		A string `csvx:"1"`
+		B string `csvx:"0"`
+		C string
+	}
+	type gapItem struct {
+		A string `csvx:"2"`
+		B string
+	}
+	testEncodeRoundTrip(t, []item{{"a", "b", "c"}}, "B,A,C\nb,a,c\n")
+	testEncodeRoundTrip(t, []gapItem{{"a", "b"}}, "B,,A\nb,,a\n")
+}
+
+// Checks that input is encoded as want and decoded back to input.
+func testEncodeRoundTrip[T any](t *testing.T, input []T, want string) {
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values(input)); err != nil {
+		t.Fatalf("EncodeWriter(%v) failed: %v", input, err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("EncodeWriter(%v)=%q, want %q", input, got, want)
+	}
+	got, err := iterx.CollectErr(DecodeReaderHeader[T](buf))
+	if err != nil {
+		t.Fatalf("DecodeReaderHeader(%q) failed: %v", want, err)
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Fatalf("DecodeReaderHeader(%q)=%v, want %v", want, got, input)
+	}
+}
+
+func TestEncodeWriter_bad(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := EncodeWriter(buf, slices.Values([]int{1})); err == nil {
+		t.Fatalf("EncodeWriter([]int) succeeded, want error")
+	}
+	type unsupported struct {
+		A []int
+	}
+	if err := EncodeWriter(buf, slices.Values([]unsupported{{}})); err == nil {
+		t.Fatalf("EncodeWriter(unsupported) succeeded, want error")
+	}
+	if err := EncodeWriter(buf, slices.Values([]badFormatItem{{}})); err == nil {
+		t.Fatalf("EncodeWriter(badFormatItem) succeeded, want error")
+	}
+	type sameIndex struct {
+		A string `csvx:"0"`
+		B string `csvx:"0"`
+	}
+	if err := EncodeWriter(buf, slices.Values([]sameIndex{{}})); err == nil {
+		t.Fatalf("EncodeWriter(sameIndex) succeeded, want error")
+	}
+}
+
+type encodeItem struct {
+	Name    string
+	Age     int `csvx:"age"`
+	Score   float64
+	Ok      bool
+	Count   uint8
+	Skipped string `csvx:"-"`
+	Hex     int    `csvx:"hex,format=FormatHex"`
+}
+
+func (encodeItem) FormatHex(i int) string {
+	return "0x" + strconv.FormatInt(int64(i), 16)
+}
+
+type badFormatItem struct {
+	A int `csvx:",format=Format"`
+}
+
+func (badFormatItem) Format(s string) string {
+	return strings.ToUpper(fmt.Sprint(s))
+}
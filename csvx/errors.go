@@ -0,0 +1,57 @@
+package csvx
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// DecodeError describes a failure to decode an input value into a field.
+// Errors yielded by the Decode functions can be inspected with [errors.As].
+type DecodeError struct {
+	Line   int    // 1-based line number in the input, or 0 if unknown
+	Column int    // 0-based column index, or -1 if not applicable
+	Header string // Column name from the header line, if any
+	Field  string // Name of the struct field, dotted for nested fields
+	Value  string // Raw input value
+	Err    error  // Underlying error
+}
+
+func (e *DecodeError) Error() string {
+	var parts []string
+	if e.Line > 0 {
+		parts = append(parts, fmt.Sprintf("line %d", e.Line))
+	}
+	if e.Column >= 0 {
+		col := fmt.Sprintf("column #%d", e.Column)
+		if e.Header != "" {
+			col += fmt.Sprintf(" (%q)", e.Header)
+		}
+		parts = append(parts, col)
+	}
+	if e.Field != "" {
+		parts = append(parts, fmt.Sprintf("field %v", e.Field))
+		parts = append(parts, fmt.Sprintf("value %q", e.Value))
+	}
+	if len(parts) == 0 {
+		return e.Err.Error()
+	}
+	return strings.Join(parts, ", ") + ": " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Strict returns an iterator that stops after yielding the first error of
+// the given iterator. Use it with the Decode functions, which otherwise
+// continue upon parsing errors.
+func Strict[T any](it iter.Seq2[T, error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for t, err := range it {
+			if !yield(t, err) || err != nil {
+				return
+			}
+		}
+	}
+}
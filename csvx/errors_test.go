@@ -0,0 +1,101 @@
+package csvx
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestDecodeError(t *testing.T) {
+	type item struct {
+		A string
+		B int `csvx:"bee"`
+	}
+	input := "A,bee\na,1\nb,x\nc,3\n"
+	var got []item
+	var errs []error
+	for x, err := range DecodeReaderHeader[item](bytes.NewBufferString(input)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, x)
+	}
+	if len(got) != 2 || len(errs) != 1 {
+		t.Fatalf("DecodeReaderHeader(%q)=%v,%v, want 2 items and 1 error",
+			input, got, errs)
+	}
+	var derr *DecodeError
+	if !errors.As(errs[0], &derr) {
+		t.Fatalf("errors.As(%v) failed, want *DecodeError", errs[0])
+	}
+	want := DecodeError{Line: 3, Column: 1, Header: "bee", Field: "B",
+		Value: "x", Err: derr.Err}
+	if *derr != want {
+		t.Fatalf("DecodeReaderHeader(%q) error=%+v, want %+v", input, *derr, want)
+	}
+	if !errors.Is(derr, strconv.ErrSyntax) {
+		t.Fatalf("errors.Is(%v, ErrSyntax)=false, want true", derr)
+	}
+}
+
+func TestDecodeError_missing(t *testing.T) {
+	type item struct {
+		A string
+		B int
+	}
+	input := "a,1\nb\n"
+	var errs []error
+	for _, err := range DecodeReader[item](bytes.NewBufferString(input)) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 1 {
+		t.Fatalf("DecodeReader(%q) errors=%v, want 1 error", input, errs)
+	}
+	var derr *DecodeError
+	if !errors.As(errs[0], &derr) || derr.Line != 2 || derr.Column != 1 {
+		t.Fatalf("DecodeReader(%q) error=%v, want line 2 column 1",
+			input, errs[0])
+	}
+}
+
+func TestDecodeError_header(t *testing.T) {
+	type item struct {
+		A string
+	}
+	input := "B\nb\n"
+	var errs []error
+	for _, err := range DecodeReaderHeader[item](bytes.NewBufferString(input)) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var derr *DecodeError
+	if len(errs) != 1 || !errors.As(errs[0], &derr) || derr.Line != 1 {
+		t.Fatalf("DecodeReaderHeader(%q) errors=%v, want 1 error on line 1",
+			input, errs)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	type item struct {
+		A int
+	}
+	input := "1\nx\n3\ny\n"
+	var got []item
+	var errs []error
+	for x, err := range Strict(DecodeReader[item](bytes.NewBufferString(input))) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, x)
+	}
+	if len(got) != 1 || len(errs) != 1 {
+		t.Fatalf("Strict(DecodeReader(%q))=%v,%v, want 1 item and 1 error",
+			input, got, errs)
+	}
+}
@@ -0,0 +1,365 @@
+package csvx
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types that get special treatment.
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	restType            = reflect.TypeFor[map[string]string]()
+)
+
+// A struct field that maps to a single column.
+// Nested struct fields are flattened into their leaf fields.
+type field struct {
+	path   []int               // Index path from the root struct
+	sf     reflect.StructField // The field itself
+	parent reflect.Type        // Type of the struct that holds the field
+	name   string              // Go name, dotted for nested fields
+	parts  []namePart          // Column name, split on nesting levels
+	idx    int                 // Column index from tag, or -1
+	named  bool                // Column name was set by a tag
+	inPtr  bool                // Field is under a struct pointer
+	mods   []string            // Tag modifiers
+
+	// Slice fields may gather several columns, either by a name prefix
+	// or by a span of column indexes.
+	prefix  bool // Column name is a prefix
+	spanned bool // Field gathers columns lo to hi
+	lo, hi  int  // Column span, hi is -1 for open-ended spans
+
+	rest bool // Field collects all unmatched columns
+
+	// Header-mode alternatives to the last part of the column name.
+	alts []string       // Alternative names, matched case sensitively
+	re   *regexp.Regexp // Pattern that the name should fully match
+
+	fixed bool // Field has a span of characters in fixed-width text
+	cols  span // The span of characters
+}
+
+// A part of a column name.
+type namePart struct {
+	s    string // The name
+	fold bool   // Match case-insensitively
+}
+
+// Returns the fields of a struct type, in order.
+// Embedded structs are flattened into their parent and nested structs
+// are flattened with a prefix.
+func structFields(t reflect.Type) ([]field, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected struct, got %v", t)
+	}
+	return appendStructFields(nil, t, nil, nil, "", nil, false)
+}
+
+// Appends the fields of t to fs, using the given path, Go name and
+// column name as prefixes. Outer holds the struct types that contain t,
+// for detecting recursive types. InPtr is true if t is under a struct
+// pointer.
+func appendStructFields(fs []field, t reflect.Type, outer []reflect.Type,
+	path []int, name string, parts []namePart, inPtr bool) ([]field, error) {
+	outer = append(slices.Clip(outer), t)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tags := splitTag(f.Tag.Get("csvx"))
+		tag, mods := tags[0], tags[1:]
+		if tag == "-" {
+			continue
+		}
+		// Exported fields of unexported embedded structs are still settable.
+		if !f.IsExported() && !(f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		fpath := append(slices.Clip(path), i)
+		fname := joinName(name, f.Name)
+
+		if isNested(f.Type, mods) {
+			st, stPtr := f.Type, inPtr
+			if st.Kind() == reflect.Pointer {
+				st, stPtr = st.Elem(), true
+			}
+			if numeric(tag) {
+				return nil, fmt.Errorf(
+					"field %q is a struct and cannot have a column index", fname)
+			}
+			if slices.Contains(outer, st) {
+				return nil, fmt.Errorf(
+					"field %q has a recursive type: %v", fname, f.Type)
+			}
+			fparts := parts
+			if f.Anonymous && tag == "" { // Flatten into parent.
+				fname = name
+			} else if tag == "" {
+				fparts = append(slices.Clip(parts), namePart{f.Name, true})
+			} else {
+				fparts = append(slices.Clip(parts), namePart{tag, false})
+			}
+			var err error
+			fs, err = appendStructFields(fs, st, outer, fpath, fname, fparts,
+				stPtr)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		fd := field{path: fpath, sf: f, parent: t, name: fname,
+			idx: -1, inPtr: inPtr, mods: mods}
+		for _, mod := range mods {
+			c, ok := strings.CutPrefix(mod, "cols=")
+			if !ok {
+				continue
+			}
+			fd.cols, fd.fixed = parseCols(c)
+			if !fd.fixed {
+				return nil, fmt.Errorf("bad cols for field %q: %q", fname, c)
+			}
+		}
+		if slices.Contains(mods, "rest") {
+			if tag != "" {
+				return nil, fmt.Errorf(
+					"field %q collects unmatched columns and cannot have a column",
+					fname)
+			}
+			if f.Type != restType {
+				return nil, fmt.Errorf(
+					"field %q collects unmatched columns but is not a %v",
+					fname, restType)
+			}
+			fd.rest = true
+			fs = append(fs, fd)
+			continue
+		}
+		if len(tag) >= 2 && strings.HasPrefix(tag, "/") &&
+			strings.HasSuffix(tag, "/") {
+			re, err := regexp.Compile("^(?:" + tag[1:len(tag)-1] + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("bad pattern for field %q: %v", fname, err)
+			}
+			fd.re = re
+		} else if strings.Contains(tag, "|") {
+			fd.alts = strings.Split(tag, "|")
+			if slices.Contains(fd.alts, "") {
+				return nil, fmt.Errorf("field %q has an empty alternative name",
+					fname)
+			}
+		} else if lo, hi, ok := parseSpan(tag); ok {
+			fd.spanned, fd.lo, fd.hi = true, lo, hi
+			tag = ""
+		} else if strings.HasSuffix(tag, "*") {
+			fd.prefix = true
+			tag = strings.TrimSuffix(tag, "*")
+			if tag == "" {
+				return nil, fmt.Errorf("field %q has an empty prefix", fname)
+			}
+		}
+		if fd.gathers() && f.Type.Kind() != reflect.Slice {
+			return nil, fmt.Errorf(
+				"field %q gathers several columns but is not a slice", fname)
+		}
+		switch {
+		case fd.re != nil:
+			fd.parts = append(slices.Clip(parts), namePart{tag, false})
+			fd.named = true
+		case fd.alts != nil:
+			fd.parts = append(slices.Clip(parts), namePart{fd.alts[0], false})
+			fd.named = true
+		case tag == "":
+			fd.parts = append(slices.Clip(parts), namePart{f.Name, true})
+		case numeric(tag):
+			fd.idx, _ = strconv.Atoi(tag) // Not expecting error.
+			fd.parts = append(slices.Clip(parts), namePart{f.Name, true})
+		default:
+			fd.parts = append(slices.Clip(parts), namePart{tag, false})
+			fd.named = true
+		}
+		fs = append(fs, fd)
+	}
+	return fs, nil
+}
+
+// Checks whether a field of type t with the given modifiers should be
+// flattened into its own fields.
+func isNested(t reflect.Type, mods []string) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return false
+	}
+	for _, mod := range mods {
+		if isMethodMod(mod) { // Parsed by a method.
+			return false
+		}
+	}
+	return true
+}
+
+// Checks whether a tag modifier refers to a parsing method.
+func isMethodMod(mod string) bool {
+	if mod == "allowempty" || mod == "optional" || mod == "rest" {
+		return false
+	}
+	for _, p := range []string{
+		"format=", "sep=", "layout=", "default=", "cols="} {
+		if strings.HasPrefix(mod, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks whether this field gathers several columns into a slice.
+func (f field) gathers() bool {
+	return f.prefix || f.spanned
+}
+
+// Parses a column span of the form "lo:hi" or "lo:".
+func parseSpan(s string) (int, int, bool) {
+	a, b, ok := strings.Cut(s, ":")
+	if !ok || !numeric(a) || (b != "" && !numeric(b)) {
+		return 0, 0, false
+	}
+	lo, _ := strconv.Atoi(a) // Not expecting error.
+	hi := -1
+	if b != "" {
+		hi, _ = strconv.Atoi(b) // Not expecting error.
+	}
+	return lo, hi, true
+}
+
+// Returns the column indexes in this field's span,
+// given the number of columns in the input.
+func (f field) spanIndexes(ncols int) []int {
+	hi := f.hi
+	if hi == -1 || hi > ncols {
+		hi = ncols
+	}
+	var idx []int
+	for i := f.lo; i < hi; i++ {
+		idx = append(idx, i)
+	}
+	return idx
+}
+
+// Returns the column name that this field maps to.
+func (f field) column() string {
+	var s []string
+	for _, p := range f.parts {
+		s = append(s, p.s)
+	}
+	return strings.Join(s, ".")
+}
+
+// Checks whether the given column name matches this field.
+func (f field) matches(col string) bool {
+	last := len(f.parts) - 1
+	for i, p := range f.parts {
+		if i > 0 {
+			var ok bool
+			col, ok = strings.CutPrefix(col, ".")
+			if !ok {
+				return false
+			}
+		}
+		if i == last && f.re != nil {
+			return f.re.MatchString(col)
+		}
+		if i == last && f.alts != nil {
+			return slices.Contains(f.alts, col)
+		}
+		if len(col) < len(p.s) {
+			return false
+		}
+		head := col[:len(p.s)]
+		col = col[len(p.s):]
+		if p.fold && !strings.EqualFold(head, p.s) {
+			return false
+		}
+		if !p.fold && head != p.s {
+			return false
+		}
+	}
+	return col == "" || f.prefix
+}
+
+// Checks whether this field should match at most one column.
+func (f field) unique() bool {
+	return f.re != nil || f.alts != nil
+}
+
+// Returns the struct that holds the field and the field's value in the
+// given root struct, allocating nil struct pointers along the way.
+func (f field) settable(root reflect.Value) (reflect.Value, reflect.Value) {
+	parent, v := root, root
+	for i, x := range f.path {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		parent = v
+		v = v.Field(x)
+	}
+	return parent, v
+}
+
+// Returns the struct that holds the field and the field's value in the
+// given root struct. Returns false if a struct pointer along the way is nil.
+func (f field) get(root reflect.Value) (reflect.Value, reflect.Value, bool) {
+	parent, v := root, root
+	for i, x := range f.path {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		parent = v
+		v = v.Field(x)
+	}
+	return parent, v, true
+}
+
+// Splits a field tag on commas. Two consecutive commas stand for a literal
+// comma, as in "sep=,,".
+func splitTag(tag string) []string {
+	var result []string
+	part := &strings.Builder{}
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] != ',':
+			part.WriteByte(tag[i])
+		case i+1 < len(tag) && tag[i+1] == ',':
+			part.WriteByte(',')
+			i++
+		default:
+			result = append(result, part.String())
+			part.Reset()
+		}
+	}
+	return append(result, part.String())
+}
+
+// Joins Go field names with a dot.
+func joinName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,221 @@
+package csvx
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fluhus/gostuff/iterx"
+)
+
+// FixedConfig holds the settings for reading fixed-width text.
+type FixedConfig struct {
+	Skip    int  // Number of lines to skip at the beginning, such as titles
+	Comment rune // Lines starting with this character are skipped, if not 0
+	Sample  int  // Number of lines for detecting columns, default 100
+}
+
+// FixedModifier modifies the settings of a fixed-width reader
+// before iteration starts.
+type FixedModifier = func(*FixedConfig)
+
+// FixedReader iterates over the entries of fixed-width text from a reader.
+// Columns are detected automatically from the first lines, as runs of
+// character positions that are not blank in all of them.
+// Values are trimmed of surrounding whitespace.
+func FixedReader(r io.Reader, mods ...FixedModifier) iter.Seq2[[]string, error] {
+	return recordFields(fixedRecords(iterx.LinesReader(r), nil, mods))
+}
+
+// FixedFile iterates over the entries of fixed-width text from a file.
+// Columns are detected like in [FixedReader].
+func FixedFile(file string, mods ...FixedModifier) iter.Seq2[[]string, error] {
+	return recordFields(fixedRecords(iterx.LinesFile(file), nil, mods))
+}
+
+// DecodeFixedReader returns an iterator over parsed instances of T,
+// from fixed-width text.
+// Each field takes the characters in the span given by its "cols" modifier.
+func DecodeFixedReader[T any](r io.Reader, mods ...FixedModifier) iter.Seq2[T, error] {
+	return decodeFixed[T](iterx.LinesReader(r), mods)
+}
+
+// DecodeFixedFile returns an iterator over parsed instances of T,
+// from fixed-width text.
+// Each field takes the characters in the span given by its "cols" modifier.
+func DecodeFixedFile[T any](file string, mods ...FixedModifier) iter.Seq2[T, error] {
+	return decodeFixed[T](iterx.LinesFile(file), mods)
+}
+
+// DecodeFixedReaderHeader returns an iterator over parsed instances of T,
+// from fixed-width text, using the first line for matching columns
+// to fields.
+// Columns are detected like in [FixedReader].
+func DecodeFixedReaderHeader[T any](r io.Reader, mods ...FixedModifier) iter.Seq2[T, error] {
+	return read[T](fixedRecords(iterx.LinesReader(r), nil, mods), true)
+}
+
+// DecodeFixedFileHeader returns an iterator over parsed instances of T,
+// from fixed-width text, using the first line for matching columns
+// to fields.
+// Columns are detected like in [FixedReader].
+func DecodeFixedFileHeader[T any](file string, mods ...FixedModifier) iter.Seq2[T, error] {
+	return read[T](fixedRecords(iterx.LinesFile(file), nil, mods), true)
+}
+
+// Decodes fixed-width lines into instances of T, using the fields' spans.
+func decodeFixed[T any](lines iter.Seq2[string, error],
+	mods []FixedModifier) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		spans, err := fixedSpans(reflect.TypeFor[T]())
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+		for t, err := range read[T](fixedRecords(lines, spans, mods), false) {
+			if !yield(t, err) {
+				return
+			}
+		}
+	}
+}
+
+// A range of character positions.
+type span struct {
+	lo, hi int // hi is excluded, -1 for the end of the line
+}
+
+// Parses a span of the form "lo-hi" or "lo-", with hi included.
+func parseCols(s string) (span, bool) {
+	a, b, ok := strings.Cut(s, "-")
+	if !ok || !numeric(a) || (b != "" && !numeric(b)) {
+		return span{}, false
+	}
+	lo, _ := strconv.Atoi(a) // Not expecting error.
+	if b == "" {
+		return span{lo, -1}, true
+	}
+	hi, _ := strconv.Atoi(b) // Not expecting error.
+	if hi < lo {
+		return span{}, false
+	}
+	return span{lo, hi + 1}, true
+}
+
+// Returns the spans of the fields of t, by field order.
+func fixedSpans(t reflect.Type) ([]span, error) {
+	fs, err := structFields(t)
+	if err != nil {
+		return nil, err
+	}
+	var spans []span
+	for _, f := range fs {
+		if f.rest {
+			continue
+		}
+		if !f.fixed {
+			return nil, fmt.Errorf("field %v has no cols modifier", f.name)
+		}
+		if f.idx != -1 || f.named || f.gathers() {
+			return nil, fmt.Errorf(
+				"field %v has a cols modifier and cannot have a column", f.name)
+		}
+		spans = append(spans, f.cols)
+	}
+	return spans, nil
+}
+
+// Splits lines into entries by the given spans.
+// If spans is nil, detects them from the first lines.
+func fixedRecords(lines iter.Seq2[string, error], spans []span,
+	mods []FixedModifier) iter.Seq2[record, error] {
+	return func(yield func(record, error) bool) {
+		spans := spans // Detected spans should not persist between iterations.
+		c := &FixedConfig{Sample: 100}
+		for _, mod := range mods {
+			mod(c)
+		}
+		// Lines waiting for column detection, and their line numbers.
+		var buf []string
+		var nums []int
+		flush := func() bool {
+			if spans == nil {
+				spans = detectSpans(buf)
+			}
+			for j, line := range buf {
+				if !yield(record{splitFixed(line, spans), nums[j]}, nil) {
+					return false
+				}
+			}
+			buf, nums = nil, nil
+			return true
+		}
+
+		i := 0
+		for line, err := range lines {
+			if err != nil {
+				yield(record{}, err)
+				return
+			}
+			i++
+			if i <= c.Skip || strings.TrimSpace(line) == "" ||
+				(c.Comment != 0 && strings.HasPrefix(line, string(c.Comment))) {
+				continue
+			}
+			buf = append(buf, line)
+			nums = append(nums, i)
+			if spans != nil || len(buf) >= c.Sample {
+				if !flush() {
+					return
+				}
+			}
+		}
+		flush()
+	}
+}
+
+// Returns the spans of runs of character positions that are not blank in
+// all of the given lines. Each span extends until the start of the next one,
+// so that values of varying widths are captured whole.
+func detectSpans(lines []string) []span {
+	var full []bool
+	for _, line := range lines {
+		for i, c := range []rune(line) {
+			if i == len(full) {
+				full = append(full, false)
+			}
+			if !unicode.IsSpace(c) {
+				full[i] = true
+			}
+		}
+	}
+	var spans []span
+	for i, f := range full {
+		if f && (i == 0 || !full[i-1]) {
+			if len(spans) > 0 {
+				spans[len(spans)-1].hi = i
+			}
+			spans = append(spans, span{i, -1})
+		}
+	}
+	return spans
+}
+
+// Returns the values of line in the given spans, trimmed of whitespace.
+func splitFixed(line string, spans []span) []string {
+	r := []rune(line)
+	result := make([]string, len(spans))
+	for i, s := range spans {
+		lo, hi := min(s.lo, len(r)), s.hi
+		if hi == -1 || hi > len(r) {
+			hi = len(r)
+		}
+		result[i] = strings.TrimSpace(string(r[lo:hi]))
+	}
+	return result
+}
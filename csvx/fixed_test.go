@@ -0,0 +1,131 @@
+package csvx
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fluhus/gostuff/iterx"
+)
+
+func TestFixedReader(t *testing.T) {
+	input := "# comment\n" +
+		"name    count  ok\n" +
+		"alice       3  true\n" +
+		"\n" +
+		"bob        12  false\n" +
+		"carol\n"
+	want := [][]string{
+		{"name", "count", "ok"},
+		{"alice", "3", "true"},
+		{"bob", "12", "false"},
+		{"carol", "", ""},
+	}
+	got, err := iterx.CollectErr(FixedReader(bytes.NewBufferString(input),
+		func(c *FixedConfig) { c.Comment = '#' }))
+	if err != nil {
+		t.Fatalf("FixedReader(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FixedReader(%q)=%q, want %q", input, got, want)
+	}
+}
+
+func TestFixedReader_sample(t *testing.T) {
+	input := "a b\n" +
+		"x  yy\n"
+	want := [][]string{{"a", "b"}, {"x", "yy"}}
+	got, err := iterx.CollectErr(FixedReader(bytes.NewBufferString(input),
+		func(c *FixedConfig) { c.Sample = 1 }))
+	if err != nil {
+		t.Fatalf("FixedReader(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FixedReader(%q)=%q, want %q", input, got, want)
+	}
+}
+
+func TestDecodeFixedReader(t *testing.T) {
+	type item struct {
+		Name  string   `csvx:",cols=0-5"`
+		Score float64  `csvx:",cols=6-9"`
+		Tags  []string `csvx:",cols=10-,sep=;"`
+	}
+	input := "title\n" +
+		"alice  1.5a;b\n" +
+		"bob   12  \n"
+	want := []item{{"alice", 1.5, []string{"a", "b"}}, {"bob", 12, nil}}
+	got, err := iterx.CollectErr(DecodeFixedReader[item](
+		bytes.NewBufferString(input), func(c *FixedConfig) { c.Skip = 1 }))
+	if err != nil {
+		t.Fatalf("DecodeFixedReader(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DecodeFixedReader(%q)=%v, want %v", input, got, want)
+	}
+}
+
+func TestDecodeFixedReader_error(t *testing.T) {
+	type item struct {
+		A int `csvx:",cols=0-2"`
+	}
+	input := "1\nx\n"
+	var errs []error
+	for _, err := range DecodeFixedReader[item](bytes.NewBufferString(input)) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var derr *DecodeError
+	if len(errs) != 1 || !errors.As(errs[0], &derr) || derr.Line != 2 {
+		t.Fatalf("DecodeFixedReader(%q) errors=%v, want 1 error on line 2",
+			input, errs)
+	}
+}
+
+func TestDecodeFixedReader_bad(t *testing.T) {
+	type noCols struct {
+		A int `csvx:",cols=0-2"`
+		B int
+	}
+	type badCols struct {
+		A int `csvx:",cols=3-2"`
+	}
+	type named struct {
+		A int `csvx:"a,cols=0-2"`
+	}
+	input := "1 2\n"
+	if _, err := iterx.CollectErr(DecodeFixedReader[noCols](
+		bytes.NewBufferString(input))); err == nil {
+		t.Fatalf("DecodeFixedReader[noCols](%q) succeeded, want error", input)
+	}
+	if _, err := iterx.CollectErr(DecodeFixedReader[badCols](
+		bytes.NewBufferString(input))); err == nil {
+		t.Fatalf("DecodeFixedReader[badCols](%q) succeeded, want error", input)
+	}
+	if _, err := iterx.CollectErr(DecodeFixedReader[named](
+		bytes.NewBufferString(input))); err == nil {
+		t.Fatalf("DecodeFixedReader[named](%q) succeeded, want error", input)
+	}
+}
+
+func TestDecodeFixedReaderHeader(t *testing.T) {
+	type item struct {
+		Name  string
+		Count int `csvx:"count"`
+		OK    *bool
+	}
+	input := "name    count  ok\n" +
+		"alice       3  true\n" +
+		"bob        12\n"
+	want := []item{{"alice", 3, ptr(true)}, {"bob", 12, nil}}
+	got, err := iterx.CollectErr(DecodeFixedReaderHeader[item](
+		bytes.NewBufferString(input)))
+	if err != nil {
+		t.Fatalf("DecodeFixedReaderHeader(%q) failed: %v", input, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DecodeFixedReaderHeader(%q)=%v, want %v", input, got, want)
+	}
+}
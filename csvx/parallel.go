@@ -0,0 +1,112 @@
+package csvx
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/fluhus/gostuff/ppln"
+)
+
+// Number of entries that are decoded together on a single goroutine.
+const parallelBatchSize = 1000
+
+// DecodeFileParallel is like [DecodeFile], but decodes entries on
+// ngoroutines goroutines.
+// Entries are yielded in their input order.
+func DecodeFileParallel[T any](file string, ngoroutines int,
+	mods ...ReaderModifier) iter.Seq2[T, error] {
+	return readParallel[T](fileRecords(file, mods), false, ngoroutines)
+}
+
+// DecodeFileHeaderParallel is like [DecodeFileHeader], but decodes entries
+// on ngoroutines goroutines.
+// Entries are yielded in their input order.
+func DecodeFileHeaderParallel[T any](file string, ngoroutines int,
+	mods ...ReaderModifier) iter.Seq2[T, error] {
+	return readParallel[T](fileRecords(file, mods), true, ngoroutines)
+}
+
+// An entry or an error, along with the decoder of the entry.
+type parallelEntry[T any] struct {
+	d   *decoder[T]
+	e   record
+	err error
+}
+
+// A decoded instance of T or an error.
+type parallelResult[T any] struct {
+	t   T
+	err error
+}
+
+// Like read, but decodes batches of entries in parallel.
+func readParallel[T any](r iter.Seq2[record, error], header bool,
+	ngoroutines int) iter.Seq2[T, error] {
+	if ngoroutines < 1 {
+		panic(fmt.Sprintf("bad number of goroutines: %d", ngoroutines))
+	}
+	return func(yield func(T, error) bool) {
+		results := ppln.SerialIter(
+			ngoroutines,
+			parallelBatches[T](r, header),
+			func(a []parallelEntry[T], i, g int) ([]parallelResult[T], error) {
+				result := make([]parallelResult[T], len(a))
+				for j, e := range a {
+					if e.err != nil {
+						result[j].err = e.err
+					} else {
+						result[j].t, result[j].err = e.d.decode(e.e)
+					}
+				}
+				return result, nil
+			})
+
+		for batch, err := range results {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, res := range batch {
+				if !yield(res.t, res.err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Groups entries into batches, attaching their decoder.
+// Errors are passed along as entries, so that the pipeline does not stop.
+func parallelBatches[T any](r iter.Seq2[record, error],
+	header bool) iter.Seq2[[]parallelEntry[T], error] {
+	return func(yield func([]parallelEntry[T], error) bool) {
+		var d *decoder[T]
+		var batch []parallelEntry[T]
+		for e, err := range r {
+			if err != nil {
+				batch = append(batch, parallelEntry[T]{err: wrapReadError(err)})
+			} else if d == nil {
+				d, err = newDecoder[T](e, header)
+				if err != nil {
+					batch = append(batch, parallelEntry[T]{err: err})
+					break
+				}
+				if !header {
+					batch = append(batch, parallelEntry[T]{d: d, e: e})
+				}
+			} else {
+				batch = append(batch, parallelEntry[T]{d: d, e: e})
+			}
+			if len(batch) == parallelBatchSize {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = nil
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch, nil)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package csvx
+
+import (
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeFileParallel(t *testing.T) {
+	type item struct {
+		A string
+		B int `csvx:"bee"`
+	}
+	lines := []string{"A,bee"}
+	for i := range 2500 {
+		if i%700 == 0 {
+			lines = append(lines, fmt.Sprintf("a%d,x", i))
+		} else {
+			lines = append(lines, fmt.Sprintf("a%d,%d", i, i))
+		}
+	}
+	file := filepath.Join(t.TempDir(), "a.csv")
+	if err := os.WriteFile(
+		file, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		x   item
+		err string
+	}
+	collect := func(it iter.Seq2[item, error]) []result {
+		var res []result
+		for x, err := range it {
+			r := result{x: x}
+			if err != nil {
+				r.err = err.Error()
+			}
+			res = append(res, r)
+		}
+		return res
+	}
+
+	for _, ngoroutines := range []int{1, 4} {
+		want := collect(DecodeFile[item](file))
+		got := collect(DecodeFileParallel[item](file, ngoroutines))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("DecodeFileParallel(%d) does not match DecodeFile: "+
+				"got %d results, want %d", ngoroutines, len(got), len(want))
+		}
+		want = collect(DecodeFileHeader[item](file))
+		got = collect(DecodeFileHeaderParallel[item](file, ngoroutines))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("DecodeFileHeaderParallel(%d) does not match "+
+				"DecodeFileHeader: got %d results, want %d",
+				ngoroutines, len(got), len(want))
+		}
+	}
+}
+
+func TestDecodeFileParallel_break(t *testing.T) {
+	type item struct {
+		A int
+	}
+	var lines []string
+	for i := range 5000 {
+		lines = append(lines, fmt.Sprint(i))
+	}
+	file := filepath.Join(t.TempDir(), "a.csv")
+	if err := os.WriteFile(
+		file, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for x, err := range DecodeFileParallel[item](file, 4) {
+		if err != nil {
+			t.Fatalf("DecodeFileParallel(%q) failed: %v", file, err)
+		}
+		if x.A != n {
+			t.Fatalf("DecodeFileParallel(%q)[%d]=%v, want %v", file, n, x.A, n)
+		}
+		n++
+		if n == 1500 {
+			break
+		}
+	}
+}
+
+func TestDecodeFileParallel_badHeader(t *testing.T) {
+	type item struct {
+		A int
+	}
+	file := filepath.Join(t.TempDir(), "a.csv")
+	if err := os.WriteFile(file, []byte("B\n1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nerr := 0
+	for _, err := range DecodeFileHeaderParallel[item](file, 2) {
+		if err == nil {
+			t.Fatalf("DecodeFileHeaderParallel(%q) succeeded, want error", file)
+		}
+		nerr++
+	}
+	if nerr != 1 {
+		t.Fatalf("DecodeFileHeaderParallel(%q) yielded %d errors, want 1",
+			file, nerr)
+	}
+}
@@ -0,0 +1,513 @@
+package iterx
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// The Err variants below operate on iterators of values and errors.
+// Errors from the underlying iterator are passed along as they are,
+// and iteration continues after them.
+
+// Map returns an iterator over the results of f on the elements of it.
+func Map[T any, U any](it iter.Seq[T], f func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for x := range it {
+			if !yield(f(x)) {
+				return
+			}
+		}
+	}
+}
+
+// Map2 returns an iterator over the results of f on the elements of it.
+func Map2[T any, S any, U any, V any](it iter.Seq2[T, S],
+	f func(T, S) (U, V)) iter.Seq2[U, V] {
+	return func(yield func(U, V) bool) {
+		for x, y := range it {
+			if !yield(f(x, y)) {
+				return
+			}
+		}
+	}
+}
+
+// MapErr returns an iterator over the results of f on the elements of it.
+// Errors returned by f are yielded like errors of the underlying iterator.
+func MapErr[T any, U any](it iter.Seq2[T, error],
+	f func(T) (U, error)) iter.Seq2[U, error] {
+	return func(yield func(U, error) bool) {
+		for x, err := range it {
+			if err != nil {
+				var u U
+				if !yield(u, err) {
+					return
+				}
+				continue
+			}
+			if !yield(f(x)) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns an iterator over the elements of it for which keep
+// returns true.
+func Filter[T any](it iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for x := range it {
+			if keep(x) && !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// Filter2 returns an iterator over the elements of it for which keep
+// returns true.
+func Filter2[T any, S any](it iter.Seq2[T, S],
+	keep func(T, S) bool) iter.Seq2[T, S] {
+	return func(yield func(T, S) bool) {
+		for x, y := range it {
+			if keep(x, y) && !yield(x, y) {
+				return
+			}
+		}
+	}
+}
+
+// FilterErr returns an iterator over the elements of it for which keep
+// returns true, and its errors.
+func FilterErr[T any](it iter.Seq2[T, error],
+	keep func(T) bool) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for x, err := range it {
+			if (err != nil || keep(x)) && !yield(x, err) {
+				return
+			}
+		}
+	}
+}
+
+// Zip returns an iterator over pairs of elements from a and b.
+// Stops when either of them stops.
+func Zip[T any, S any](a iter.Seq[T], b iter.Seq[S]) iter.Seq2[T, S] {
+	return func(yield func(T, S) bool) {
+		next, stop := iter.Pull(b)
+		defer stop()
+		for x := range a {
+			y, ok := next()
+			if !ok || !yield(x, y) {
+				return
+			}
+		}
+	}
+}
+
+// Enumerate returns an iterator over the elements of it and their 0-based
+// indexes, like in a range expression over a slice.
+func Enumerate[T any](it iter.Seq[T]) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		for x := range it {
+			if !yield(i, x) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Chunk returns an iterator over consecutive slices of up to n elements.
+// All slices have n elements except maybe the last one.
+// Panics if n is less than 1.
+func Chunk[T any](it iter.Seq[T], n int) iter.Seq[[]T] {
+	if n < 1 {
+		panic(fmt.Sprintf("bad chunk size: %d", n))
+	}
+	return func(yield func([]T) bool) {
+		var chunk []T
+		for x := range it {
+			chunk = append(chunk, x)
+			if len(chunk) == n {
+				if !yield(chunk) {
+					return
+				}
+				chunk = nil
+			}
+		}
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// ChunkErr returns an iterator over consecutive slices of up to n elements.
+// Errors are yielded with a nil slice, without breaking the current chunk.
+// Panics if n is less than 1.
+func ChunkErr[T any](it iter.Seq2[T, error], n int) iter.Seq2[[]T, error] {
+	if n < 1 {
+		panic(fmt.Sprintf("bad chunk size: %d", n))
+	}
+	return func(yield func([]T, error) bool) {
+		var chunk []T
+		for x, err := range it {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			chunk = append(chunk, x)
+			if len(chunk) == n {
+				if !yield(chunk, nil) {
+					return
+				}
+				chunk = nil
+			}
+		}
+		if len(chunk) > 0 {
+			yield(chunk, nil)
+		}
+	}
+}
+
+// Chunk2 returns an iterator over consecutive slices of up to n pairs,
+// split into their first and second elements.
+// All slices have n elements except maybe the last ones.
+// Panics if n is less than 1.
+func Chunk2[T any, S any](it iter.Seq2[T, S], n int) iter.Seq2[[]T, []S] {
+	if n < 1 {
+		panic(fmt.Sprintf("bad chunk size: %d", n))
+	}
+	return func(yield func([]T, []S) bool) {
+		var ct []T
+		var cs []S
+		for x, y := range it {
+			ct, cs = append(ct, x), append(cs, y)
+			if len(ct) == n {
+				if !yield(ct, cs) {
+					return
+				}
+				ct, cs = nil, nil
+			}
+		}
+		if len(ct) > 0 {
+			yield(ct, cs)
+		}
+	}
+}
+
+// Window returns an iterator over sliding windows of n consecutive
+// elements. Each window is a new slice.
+// Yields nothing if it has less than n elements.
+// Panics if n is less than 1.
+func Window[T any](it iter.Seq[T], n int) iter.Seq[[]T] {
+	if n < 1 {
+		panic(fmt.Sprintf("bad window size: %d", n))
+	}
+	return func(yield func([]T) bool) {
+		var w []T
+		for x := range it {
+			if len(w) == n {
+				w = slices.Clone(w[1:])
+			}
+			w = append(w, x)
+			if len(w) == n && !yield(w) {
+				return
+			}
+		}
+	}
+}
+
+// Window2 returns an iterator over sliding windows of n consecutive pairs,
+// split into their first and second elements. Each window is a new pair
+// of slices.
+// Yields nothing if it has less than n elements.
+// Panics if n is less than 1.
+func Window2[T any, S any](it iter.Seq2[T, S], n int) iter.Seq2[[]T, []S] {
+	if n < 1 {
+		panic(fmt.Sprintf("bad window size: %d", n))
+	}
+	return func(yield func([]T, []S) bool) {
+		var wt []T
+		var ws []S
+		for x, y := range it {
+			if len(wt) == n {
+				wt, ws = slices.Clone(wt[1:]), slices.Clone(ws[1:])
+			}
+			wt, ws = append(wt, x), append(ws, y)
+			if len(wt) == n && !yield(wt, ws) {
+				return
+			}
+		}
+	}
+}
+
+// WindowErr returns an iterator over sliding windows of n consecutive
+// elements. Each window is a new slice.
+// Errors are yielded with a nil slice, without breaking the current window.
+// Panics if n is less than 1.
+func WindowErr[T any](it iter.Seq2[T, error], n int) iter.Seq2[[]T, error] {
+	if n < 1 {
+		panic(fmt.Sprintf("bad window size: %d", n))
+	}
+	return func(yield func([]T, error) bool) {
+		var w []T
+		for x, err := range it {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			if len(w) == n {
+				w = slices.Clone(w[1:])
+			}
+			w = append(w, x)
+			if len(w) == n && !yield(w, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Concat returns an iterator over the elements of the given iterators,
+// one after the other.
+func Concat[T any](its ...iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, it := range its {
+			for x := range it {
+				if !yield(x) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Concat2 returns an iterator over the elements of the given iterators,
+// one after the other.
+func Concat2[T any, S any](its ...iter.Seq2[T, S]) iter.Seq2[T, S] {
+	return func(yield func(T, S) bool) {
+		for _, it := range its {
+			for x, y := range it {
+				if !yield(x, y) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// FlatMap returns an iterator over the elements of the iterators that f
+// returns for the elements of it.
+func FlatMap[T any, U any](it iter.Seq[T], f func(T) iter.Seq[U]) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for x := range it {
+			for u := range f(x) {
+				if !yield(u) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// FlatMap2 returns an iterator over the elements of the iterators that f
+// returns for the elements of it.
+func FlatMap2[T any, S any, U any, V any](it iter.Seq2[T, S],
+	f func(T, S) iter.Seq2[U, V]) iter.Seq2[U, V] {
+	return func(yield func(U, V) bool) {
+		for x, y := range it {
+			for u, v := range f(x, y) {
+				if !yield(u, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// FlatMapErr returns an iterator over the elements of the iterators that f
+// returns for the elements of it.
+func FlatMapErr[T any, U any](it iter.Seq2[T, error],
+	f func(T) iter.Seq2[U, error]) iter.Seq2[U, error] {
+	return func(yield func(U, error) bool) {
+		for x, err := range it {
+			if err != nil {
+				var u U
+				if !yield(u, err) {
+					return
+				}
+				continue
+			}
+			for u, err := range f(x) {
+				if !yield(u, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// TakeWhile returns an iterator over the elements of it, that stops at
+// the first element for which f returns false.
+func TakeWhile[T any](it iter.Seq[T], f func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for x := range it {
+			if !f(x) || !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// TakeWhile2 returns an iterator over the elements of it, that stops at
+// the first element for which f returns false.
+func TakeWhile2[T any, S any](it iter.Seq2[T, S],
+	f func(T, S) bool) iter.Seq2[T, S] {
+	return func(yield func(T, S) bool) {
+		for x, y := range it {
+			if !f(x, y) || !yield(x, y) {
+				return
+			}
+		}
+	}
+}
+
+// TakeWhileErr returns an iterator over the elements of it, that stops at
+// the first element for which f returns false.
+func TakeWhileErr[T any](it iter.Seq2[T, error],
+	f func(T) bool) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for x, err := range it {
+			if err == nil && !f(x) {
+				return
+			}
+			if !yield(x, err) {
+				return
+			}
+		}
+	}
+}
+
+// Dedup returns an iterator over the elements of it, without consecutive
+// duplicates.
+func Dedup[T comparable](it iter.Seq[T]) iter.Seq[T] {
+	return DedupFunc(it, func(a, b T) bool { return a == b })
+}
+
+// DedupFunc returns an iterator over the elements of it, without
+// consecutive elements for which eq returns true.
+func DedupFunc[T any](it iter.Seq[T], eq func(T, T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		var last T
+		first := true
+		for x := range it {
+			if !first && eq(last, x) {
+				continue
+			}
+			first = false
+			last = x
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// Dedup2 returns an iterator over the elements of it, without consecutive
+// duplicates.
+func Dedup2[T comparable, S comparable](it iter.Seq2[T, S]) iter.Seq2[T, S] {
+	return DedupFunc2(it, func(a1 T, b1 S, a2 T, b2 S) bool {
+		return a1 == a2 && b1 == b2
+	})
+}
+
+// DedupFunc2 returns an iterator over the elements of it, without
+// consecutive elements for which eq returns true.
+func DedupFunc2[T any, S any](it iter.Seq2[T, S],
+	eq func(T, S, T, S) bool) iter.Seq2[T, S] {
+	return func(yield func(T, S) bool) {
+		var lastT T
+		var lastS S
+		first := true
+		for x, y := range it {
+			if !first && eq(lastT, lastS, x, y) {
+				continue
+			}
+			first = false
+			lastT, lastS = x, y
+			if !yield(x, y) {
+				return
+			}
+		}
+	}
+}
+
+// DedupErr returns an iterator over the elements of it, without
+// consecutive duplicates. Errors do not separate duplicates.
+func DedupErr[T comparable](it iter.Seq2[T, error]) iter.Seq2[T, error] {
+	return DedupFuncErr(it, func(a, b T) bool { return a == b })
+}
+
+// DedupFuncErr returns an iterator over the elements of it, without
+// consecutive elements for which eq returns true.
+// Errors do not separate duplicates.
+func DedupFuncErr[T any](it iter.Seq2[T, error],
+	eq func(T, T) bool) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var last T
+		first := true
+		for x, err := range it {
+			if err == nil {
+				if !first && eq(last, x) {
+					continue
+				}
+				first = false
+				last = x
+			}
+			if !yield(x, err) {
+				return
+			}
+		}
+	}
+}
+
+// Reduce calls f on the accumulated value and each of the elements of it,
+// starting with init, and returns the final value.
+func Reduce[T any, U any](it iter.Seq[T], init U, f func(U, T) U) U {
+	acc := init
+	for x := range it {
+		acc = f(acc, x)
+	}
+	return acc
+}
+
+// Reduce2 calls f on the accumulated value and each of the elements of it,
+// starting with init, and returns the final value.
+func Reduce2[T any, S any, U any](it iter.Seq2[T, S], init U,
+	f func(U, T, S) U) U {
+	acc := init
+	for x, y := range it {
+		acc = f(acc, x, y)
+	}
+	return acc
+}
+
+// ReduceErr is like [Reduce], but stops at the first error,
+// returning the value accumulated so far.
+func ReduceErr[T any, U any](it iter.Seq2[T, error], init U,
+	f func(U, T) U) (U, error) {
+	acc := init
+	for x, err := range it {
+		if err != nil {
+			return acc, err
+		}
+		acc = f(acc, x)
+	}
+	return acc, nil
+}
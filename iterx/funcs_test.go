@@ -0,0 +1,384 @@
+package iterx
+
+import (
+	"errors"
+	"iter"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// Returns an iterator over the given values, with an error instead of
+// negative values.
+func withErrors(a []int) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		for _, x := range a {
+			var err error
+			if x < 0 {
+				x, err = 0, errors.New(strconv.Itoa(x))
+			}
+			if !yield(x, err) {
+				return
+			}
+		}
+	}
+}
+
+// Collects values and error messages, with "!" before error messages.
+func collectWithErrors[T any](it iter.Seq2[T, error]) []any {
+	var result []any
+	for x, err := range it {
+		if err != nil {
+			result = append(result, "!"+err.Error())
+		} else {
+			result = append(result, x)
+		}
+	}
+	return result
+}
+
+func TestMap(t *testing.T) {
+	input := []int{1, 2, 3}
+	got := slices.Collect(Map(slices.Values(input), strconv.Itoa))
+	if want := []string{"1", "2", "3"}; !slices.Equal(got, want) {
+		t.Fatalf("Map(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestMap2(t *testing.T) {
+	input := []string{"a", "b"}
+	got := maps.Collect(Map2(slices.All(input), func(i int, s string) (string, int) {
+		return s, i * 10
+	}))
+	if want := map[string]int{"a": 0, "b": 10}; !maps.Equal(got, want) {
+		t.Fatalf("Map2(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestMapErr(t *testing.T) {
+	input := []int{1, -1, 2, 3}
+	got := collectWithErrors(MapErr(withErrors(input), func(i int) (int, error) {
+		if i == 2 {
+			return 0, errors.New("two")
+		}
+		return i * 10, nil
+	}))
+	want := []any{10, "!-1", "!two", 30}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MapErr(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5}
+	even := func(i int) bool { return i%2 == 0 }
+	got := slices.Collect(Filter(slices.Values(input), even))
+	if want := []int{2, 4}; !slices.Equal(got, want) {
+		t.Fatalf("Filter(%v)=%v, want %v", input, got, want)
+	}
+	got = slices.Collect(Limit(Filter(slices.Values(input), even), 1))
+	if want := []int{2}; !slices.Equal(got, want) {
+		t.Fatalf("Limit(Filter(%v),1)=%v, want %v", input, got, want)
+	}
+}
+
+func TestFilter2(t *testing.T) {
+	input := []int{5, 6, 7}
+	got := maps.Collect(Filter2(slices.All(input), func(i, x int) bool {
+		return i != 1
+	}))
+	if want := map[int]int{0: 5, 2: 7}; !maps.Equal(got, want) {
+		t.Fatalf("Filter2(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestFilterErr(t *testing.T) {
+	input := []int{1, -1, 2, 3}
+	got := collectWithErrors(FilterErr(withErrors(input), func(i int) bool {
+		return i != 2
+	}))
+	if want := []any{1, "!-1", 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("FilterErr(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestZip(t *testing.T) {
+	tests := []struct {
+		a, b  []int
+		wantA []int
+	}{
+		{[]int{1, 2, 3}, []int{4, 5, 6}, []int{1, 2, 3}},
+		{[]int{1, 2, 3}, []int{4}, []int{1}},
+		{[]int{1}, []int{4, 5}, []int{1}},
+		{nil, []int{4, 5}, nil},
+	}
+	for _, test := range tests {
+		var gotA, gotB []int
+		for x, y := range Zip(slices.Values(test.a), slices.Values(test.b)) {
+			gotA = append(gotA, x)
+			gotB = append(gotB, y)
+		}
+		if !slices.Equal(gotA, test.wantA) ||
+			!slices.Equal(gotB, test.b[:len(test.wantA)]) {
+			t.Errorf("Zip(%v,%v)=%v,%v, want %v,%v", test.a, test.b,
+				gotA, gotB, test.wantA, test.b[:len(test.wantA)])
+		}
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	input := []string{"a", "b", "c"}
+	var got []string
+	for i, x := range Enumerate(slices.Values(input)) {
+		got = append(got, strconv.Itoa(i)+x)
+	}
+	if want := []string{"0a", "1b", "2c"}; !slices.Equal(got, want) {
+		t.Fatalf("Enumerate(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	input := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		n    int
+		want [][]int
+	}{
+		{1, [][]int{{1}, {2}, {3}, {4}, {5}}},
+		{2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{5, [][]int{{1, 2, 3, 4, 5}}},
+		{6, [][]int{{1, 2, 3, 4, 5}}},
+	}
+	for _, test := range tests {
+		got := slices.Collect(Chunk(slices.Values(input), test.n))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Chunk(%v,%v)=%v, want %v", input, test.n, got, test.want)
+		}
+	}
+}
+
+func TestChunkErr(t *testing.T) {
+	input := []int{1, 2, -1, 3, 4, 5}
+	got := collectWithErrors(ChunkErr(withErrors(input), 2))
+	want := []any{[]int{1, 2}, "!-1", []int{3, 4}, []int{5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ChunkErr(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestChunk2(t *testing.T) {
+	input := []int{5, 6, 7}
+	var got [][]int
+	for a, b := range Chunk2(slices.All(input), 2) {
+		got = append(got, a, b)
+	}
+	want := [][]int{{0, 1}, {5, 6}, {2}, {7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Chunk2(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	input := []int{1, 2, 3, 4}
+	tests := []struct {
+		n    int
+		want [][]int
+	}{
+		{1, [][]int{{1}, {2}, {3}, {4}}},
+		{2, [][]int{{1, 2}, {2, 3}, {3, 4}}},
+		{4, [][]int{{1, 2, 3, 4}}},
+		{5, nil},
+	}
+	for _, test := range tests {
+		got := slices.Collect(Window(slices.Values(input), test.n))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Window(%v,%v)=%v, want %v", input, test.n, got, test.want)
+		}
+	}
+}
+
+func TestWindow2(t *testing.T) {
+	input := []int{5, 6, 7}
+	var got [][]int
+	for a, b := range Window2(slices.All(input), 2) {
+		got = append(got, a, b)
+	}
+	want := [][]int{{0, 1}, {5, 6}, {1, 2}, {6, 7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Window2(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestWindowErr(t *testing.T) {
+	input := []int{1, 2, -1, 3, 4}
+	got := collectWithErrors(WindowErr(withErrors(input), 2))
+	want := []any{[]int{1, 2}, "!-1", []int{2, 3}, []int{3, 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("WindowErr(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	got := slices.Collect(Concat(slices.Values([]int{1, 2}),
+		slices.Values([]int{}), slices.Values([]int{3})))
+	if want := []int{1, 2, 3}; !slices.Equal(got, want) {
+		t.Fatalf("Concat(...)=%v, want %v", got, want)
+	}
+	got = slices.Collect(Limit(Concat(slices.Values([]int{1, 2}),
+		slices.Values([]int{3})), 2))
+	if want := []int{1, 2}; !slices.Equal(got, want) {
+		t.Fatalf("Limit(Concat(...),2)=%v, want %v", got, want)
+	}
+}
+
+func TestConcat2(t *testing.T) {
+	var got []int
+	for i, x := range Concat2(slices.All([]int{5, 6}), slices.All([]int{7})) {
+		got = append(got, i, x)
+	}
+	if want := []int{0, 5, 1, 6, 0, 7}; !slices.Equal(got, want) {
+		t.Fatalf("Concat2(...)=%v, want %v", got, want)
+	}
+}
+
+func TestFlatMap(t *testing.T) {
+	input := []int{1, 0, 3}
+	got := slices.Collect(FlatMap(slices.Values(input), func(i int) iter.Seq[int] {
+		return slices.Values(slices.Repeat([]int{i}, i))
+	}))
+	if want := []int{1, 3, 3, 3}; !slices.Equal(got, want) {
+		t.Fatalf("FlatMap(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestFlatMap2(t *testing.T) {
+	input := []int{2, 0, 1}
+	var got []int
+	for i, x := range FlatMap2(slices.All(input),
+		func(i, x int) iter.Seq2[int, int] {
+			return slices.All(slices.Repeat([]int{i}, x))
+		}) {
+		got = append(got, i, x)
+	}
+	if want := []int{0, 0, 1, 0, 0, 2}; !slices.Equal(got, want) {
+		t.Fatalf("FlatMap2(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestFlatMapErr(t *testing.T) {
+	input := []int{1, -1, 2}
+	got := collectWithErrors(FlatMapErr(withErrors(input),
+		func(i int) iter.Seq2[int, error] {
+			return withErrors([]int{i, -i})
+		}))
+	want := []any{1, "!-1", "!-1", 2, "!-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FlatMapErr(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestTakeWhile(t *testing.T) {
+	input := []int{1, 2, 3, 1}
+	got := slices.Collect(TakeWhile(slices.Values(input), func(i int) bool {
+		return i < 3
+	}))
+	if want := []int{1, 2}; !slices.Equal(got, want) {
+		t.Fatalf("TakeWhile(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestTakeWhile2(t *testing.T) {
+	input := []int{1, -1, 2}
+	got := collectWithErrors(TakeWhile2(withErrors(input),
+		func(_ int, err error) bool {
+			return err == nil
+		}))
+	if want := []any{1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TakeWhile2(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestTakeWhileErr(t *testing.T) {
+	input := []int{1, -1, 2, 3, 1}
+	got := collectWithErrors(TakeWhileErr(withErrors(input),
+		func(i int) bool {
+			return i < 3
+		}))
+	if want := []any{1, "!-1", 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("TakeWhileErr(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	input := []int{1, 1, 2, 1, 3, 3, 3}
+	got := slices.Collect(Dedup(slices.Values(input)))
+	if want := []int{1, 2, 1, 3}; !slices.Equal(got, want) {
+		t.Fatalf("Dedup(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestDedupFunc(t *testing.T) {
+	input := []int{1, 3, 2, 4, 5}
+	got := slices.Collect(DedupFunc(slices.Values(input), func(a, b int) bool {
+		return a%2 == b%2
+	}))
+	if want := []int{1, 2, 5}; !slices.Equal(got, want) {
+		t.Fatalf("DedupFunc(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestDedup2(t *testing.T) {
+	a := []int{1, 1, 2, 2}
+	b := []int{5, 5, 5, 6}
+	var got []int
+	for x, y := range Dedup2(Zip(slices.Values(a), slices.Values(b))) {
+		got = append(got, x, y)
+	}
+	if want := []int{1, 5, 2, 5, 2, 6}; !slices.Equal(got, want) {
+		t.Fatalf("Dedup2(%v,%v)=%v, want %v", a, b, got, want)
+	}
+}
+
+func TestDedupErr(t *testing.T) {
+	input := []int{1, 1, -1, 1, 2, -2, -2, 2}
+	got := collectWithErrors(DedupErr(withErrors(input)))
+	want := []any{1, "!-1", 2, "!-2", "!-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DedupErr(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	input := []int{1, 2, 3}
+	got := Reduce(slices.Values(input), "", func(s string, i int) string {
+		return s + strconv.Itoa(i)
+	})
+	if want := "123"; got != want {
+		t.Fatalf("Reduce(%v)=%q, want %q", input, got, want)
+	}
+}
+
+func TestReduce2(t *testing.T) {
+	input := []int{5, 6, 7}
+	got := Reduce2(slices.All(input), 0, func(acc, i, x int) int {
+		return acc + i*x
+	})
+	if want := 20; got != want {
+		t.Fatalf("Reduce2(%v)=%v, want %v", input, got, want)
+	}
+}
+
+func TestReduceErr(t *testing.T) {
+	sum := func(a, b int) int { return a + b }
+	input := []int{1, 2, 3}
+	got, err := ReduceErr(withErrors(input), 10, sum)
+	if err != nil || got != 16 {
+		t.Fatalf("ReduceErr(%v)=%v,%v, want 16,nil", input, got, err)
+	}
+	input = []int{1, 2, -1, 3}
+	got, err = ReduceErr(withErrors(input), 10, sum)
+	if err == nil || got != 13 {
+		t.Fatalf("ReduceErr(%v)=%v,%v, want 13,error", input, got, err)
+	}
+}
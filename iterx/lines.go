@@ -1,4 +1,10 @@
 // Package iterx provides convenience functions for iterators.
+//
+// Most combinators come in three variants: one for [iter.Seq], one for
+// [iter.Seq2] with a 2 suffix, and one for iterators of values and errors
+// with an Err suffix. [Zip] and [Enumerate] have no variants, since they
+// turn an [iter.Seq] into an [iter.Seq2]. [Concat2] serves iterators of
+// values and errors as they are.
 package iterx
 
 import (
@@ -11,21 +17,19 @@ import (
 )
 
 // LinesReader iterates over text lines from a reader.
+// Lines may be of any length.
 func LinesReader(r io.Reader) iter.Seq2[string, error] {
 	return func(yield func(string, error) bool) {
-		sc := bufio.NewScanner(r)
-		for sc.Scan() {
-			if !yield(sc.Text(), nil) {
+		for line, err := range NewLineReader(r).Lines() {
+			if !yield(string(line), err) {
 				return
 			}
 		}
-		if err := sc.Err(); err != nil {
-			yield("", err)
-		}
 	}
 }
 
 // LinesFile iterates over text lines from a reader.
+// Lines may be of any length.
 func LinesFile(file string) iter.Seq2[string, error] {
 	return func(yield func(string, error) bool) {
 		f, err := aio.Open(file)
@@ -34,14 +38,83 @@ func LinesFile(file string) iter.Seq2[string, error] {
 			return
 		}
 		defer f.Close()
-		sc := bufio.NewScanner(f)
-		for sc.Scan() {
-			if !yield(sc.Text(), nil) {
+		for line, err := range LinesReader(f) {
+			if !yield(line, err) {
 				return
 			}
 		}
-		if err := sc.Err(); err != nil {
-			yield("", err)
+	}
+}
+
+// A LineReader reads text lines of any length, and keeps track of their
+// line numbers and byte offsets.
+// Line endings ("\n" or "\r\n") are removed from the returned lines.
+type LineReader struct {
+	KeepCR bool // Keep "\r" at the end of lines
+
+	r    *bufio.Reader
+	buf  []byte // For lines longer than r's buffer
+	num  int    // Number of the last line
+	off  int64  // Offset of the last line
+	next int64  // Offset of the next line
+}
+
+// NewLineReader returns a LineReader that reads from r.
+func NewLineReader(r io.Reader) *LineReader {
+	return &LineReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next line, or io.EOF at the end of the input.
+// The returned slice is only valid until the next call to Next.
+func (r *LineReader) Next() ([]byte, error) {
+	line, err := r.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		r.buf = append(r.buf[:0], line...)
+		for err == bufio.ErrBufferFull {
+			line, err = r.r.ReadSlice('\n')
+			r.buf = append(r.buf, line...)
+		}
+		line = r.buf
+	}
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, err
+	}
+	r.num++
+	r.off = r.next
+	r.next += int64(len(line))
+	if len(line) > 0 && line[len(line)-1] == '\n' {
+		line = line[:len(line)-1]
+	}
+	if !r.KeepCR && len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, nil
+}
+
+// Num returns the 1-based line number of the last line returned by Next.
+func (r *LineReader) Num() int {
+	return r.num
+}
+
+// Offset returns the byte offset of the start of the last line returned
+// by Next.
+func (r *LineReader) Offset() int64 {
+	return r.off
+}
+
+// Lines returns an iterator over the remaining lines.
+// Yielded slices are only valid until the next iteration.
+// Num and Offset may be called during the iteration for the current line.
+func (r *LineReader) Lines() iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		for {
+			line, err := r.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(line, err) || err != nil {
+				return
+			}
 		}
 	}
 }
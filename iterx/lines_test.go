@@ -0,0 +1,117 @@
+package iterx
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fluhus/gostuff/aio"
+)
+
+func TestLinesReader(t *testing.T) {
+	long := strings.Repeat("x", 200000)
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{"a\n", []string{"a"}},
+		{"a\nbb\n\nc", []string{"a", "bb", "", "c"}},
+		{"a\r\nb\r\n", []string{"a", "b"}},
+		{long + "\n" + long, []string{long, long}},
+	}
+	for _, test := range tests {
+		got, err := CollectErr(LinesReader(strings.NewReader(test.input)))
+		if err != nil {
+			t.Fatalf("LinesReader(%.20q) failed: %v", test.input, err)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("LinesReader(%.20q)=%.20q, want %.20q",
+				test.input, got, test.want)
+		}
+	}
+}
+
+func TestLinesFile(t *testing.T) {
+	long := strings.Repeat("y", 100000)
+	input := "a\n" + long + "\nb\n"
+	want := []string{"a", long, "b"}
+	for _, suffix := range []string{".txt", ".txt.gz"} {
+		file := filepath.Join(t.TempDir(), "a"+suffix)
+		if err := writeFile(file, input); err != nil {
+			t.Fatal(err)
+		}
+		got, err := CollectErr(LinesFile(file))
+		if err != nil {
+			t.Fatalf("LinesFile(%q) failed: %v", file, err)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("LinesFile(%q)=%.20q, want %.20q", file, got, want)
+		}
+	}
+	if _, err := CollectErr(LinesFile(filepath.Join(t.TempDir(), "x"))); err == nil {
+		t.Fatalf("LinesFile(nonexistent) succeeded, want error")
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	long := strings.Repeat("z", 5000)
+	input := "ab\r\n" + long + "\n\nc"
+	type line struct {
+		text string
+		num  int
+		off  int64
+	}
+	tests := []struct {
+		keepCR bool
+		want   []line
+	}{
+		{false, []line{{"ab", 1, 0}, {long, 2, 4}, {"", 3, 5005}, {"c", 4, 5006}}},
+		{true, []line{{"ab\r", 1, 0}, {long, 2, 4}, {"", 3, 5005}, {"c", 4, 5006}}},
+	}
+	for _, test := range tests {
+		r := NewLineReader(strings.NewReader(input))
+		r.KeepCR = test.keepCR
+		var got []line
+		for b, err := range r.Lines() {
+			if err != nil {
+				t.Fatalf("Lines() failed: %v", err)
+			}
+			got = append(got, line{string(b), r.Num(), r.Offset()})
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("Lines(KeepCR=%v)=%.30v, want %.30v",
+				test.keepCR, got, test.want)
+		}
+	}
+}
+
+func TestLineReader_offsets(t *testing.T) {
+	input := []byte("hello\nworld\r\n\nbye")
+	r := NewLineReader(bytes.NewReader(input))
+	for b, err := range r.Lines() {
+		if err != nil {
+			t.Fatalf("Lines() failed: %v", err)
+		}
+		if !bytes.HasPrefix(input[r.Offset():], b) {
+			t.Fatalf("Offset()=%d for line %q, input there is %q",
+				r.Offset(), b, input[r.Offset():])
+		}
+	}
+}
+
+// Writes a file, compressed according to its suffix.
+func writeFile(file, content string) error {
+	f, err := aio.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte(content)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,126 @@
+package iterx
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+
+	"github.com/fluhus/gostuff/heaps"
+)
+
+// Merge returns an iterator over the elements of the given sorted
+// iterators, sorted by cmp.
+// Equal elements are yielded in the order of their iterators.
+func Merge[T any](cmp func(a, b T) int, its ...iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		var nexts []func() (T, bool)
+		for _, it := range its {
+			next, stop := iter.Pull(it)
+			defer stop()
+			nexts = append(nexts, next)
+		}
+		h := heaps.New(func(a, b mergeItem[T]) bool {
+			c := cmp(a.t, b.t)
+			return c < 0 || (c == 0 && a.i < b.i)
+		})
+		for i, next := range nexts {
+			if t, ok := next(); ok {
+				h.Push(mergeItem[T]{t, i})
+			}
+		}
+		for h.Len() > 0 {
+			item := h.Head()
+			if !yield(item.t) {
+				return
+			}
+			t, ok := nexts[item.i]()
+			if !ok {
+				h.Pop()
+				continue
+			}
+			h.View()[0].t = t
+			h.Fix(0)
+		}
+	}
+}
+
+// An element in a merge, with the index of its source.
+type mergeItem[T any] struct {
+	t T
+	i int
+}
+
+// JoinType determines which groups are yielded by [MergeJoin].
+type JoinType int
+
+const (
+	InnerJoin JoinType = iota // Keys that appear in both iterators
+	LeftJoin                  // Keys that appear in the first iterator
+	OuterJoin                 // Keys that appear in either iterator
+)
+
+// MergeJoin returns an iterator over groups of elements of a and b that
+// share a key, by key order.
+// A and b should be sorted by their keys.
+//
+// Consecutive elements with equal keys are grouped like in
+// [Unreader.GroupBy]. Depending on the join type, keys that appear in only
+// one of the iterators are yielded with a nil group for the other.
+func MergeJoin[A any, B any, K cmp.Ordered](
+	a iter.Seq[A], b iter.Seq[B],
+	keyA func(A) K, keyB func(B) K,
+	join JoinType,
+) iter.Seq2[[]A, []B] {
+	return func(yield func([]A, []B) bool) {
+		nextA, stopA := iter.Pull(NewUnreader(a).GroupBy(func(x, y A) bool {
+			return keyA(x) == keyA(y)
+		}))
+		defer stopA()
+		nextB, stopB := iter.Pull(NewUnreader(b).GroupBy(func(x, y B) bool {
+			return keyB(x) == keyB(y)
+		}))
+		defer stopB()
+
+		ga, okA := nextGroup(nextA)
+		gb, okB := nextGroup(nextB)
+		for okA || okB {
+			c := 0
+			switch {
+			case !okB:
+				c = -1
+			case !okA:
+				c = 1
+			default:
+				c = cmp.Compare(keyA(ga[0]), keyB(gb[0]))
+			}
+
+			switch {
+			case c < 0:
+				if join != InnerJoin && !yield(ga, nil) {
+					return
+				}
+				ga, okA = nextGroup(nextA)
+			case c > 0:
+				if join == OuterJoin && !yield(nil, gb) {
+					return
+				}
+				gb, okB = nextGroup(nextB)
+			default:
+				if !yield(ga, gb) {
+					return
+				}
+				ga, okA = nextGroup(nextA)
+				gb, okB = nextGroup(nextB)
+			}
+		}
+	}
+}
+
+// Reads the next group and collects it.
+func nextGroup[T any](next func() (iter.Seq[T], bool)) ([]T, bool) {
+	g, ok := next()
+	if !ok {
+		return nil, false
+	}
+	return slices.Collect(g), true
+}
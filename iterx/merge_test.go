@@ -0,0 +1,102 @@
+package iterx
+
+import (
+	"cmp"
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	type item struct {
+		k, src int
+	}
+	cmpItem := func(a, b item) int { return cmp.Compare(a.k, b.k) }
+	input := [][]item{
+		{{1, 0}, {3, 0}, {5, 0}},
+		{},
+		{{1, 2}, {2, 2}, {6, 2}, {7, 2}},
+		{{0, 3}, {5, 3}},
+	}
+	var its []iter.Seq[item]
+	for _, s := range input {
+		its = append(its, slices.Values(s))
+	}
+	got := slices.Collect(Merge(cmpItem, its...))
+	want := []item{{0, 3}, {1, 0}, {1, 2}, {2, 2}, {3, 0},
+		{5, 0}, {5, 3}, {6, 2}, {7, 2}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Merge(%v)=%v, want %v", input, got, want)
+	}
+
+	got = slices.Collect(Limit(Merge(cmpItem, its...), 3))
+	if !slices.Equal(got, want[:3]) {
+		t.Fatalf("Limit(Merge(%v),3)=%v, want %v", input, got, want[:3])
+	}
+	if got := slices.Collect(Merge(cmpItem)); len(got) != 0 {
+		t.Fatalf("Merge()=%v, want []", got)
+	}
+}
+
+func TestMergeJoin(t *testing.T) {
+	a := []string{"a1", "b1", "b2", "d1", "f1"}
+	b := []int{2, 3, 3, 4, 6}
+	keyA := func(s string) int { return int(s[0]-'a') + 1 }
+	keyB := func(i int) int { return i }
+
+	format := func(it iter.Seq2[[]string, []int]) []string {
+		var result []string
+		for ga, gb := range it {
+			s := strings.Join(ga, "+") + ":"
+			for _, x := range gb {
+				s += string(rune('0' + x))
+			}
+			result = append(result, s)
+		}
+		return result
+	}
+	tests := []struct {
+		join JoinType
+		want []string
+	}{
+		{InnerJoin, []string{"b1+b2:2", "d1:4", "f1:6"}},
+		{LeftJoin, []string{"a1:", "b1+b2:2", "d1:4", "f1:6"}},
+		{OuterJoin, []string{"a1:", "b1+b2:2", ":33", "d1:4", "f1:6"}},
+	}
+	for _, test := range tests {
+		got := format(MergeJoin(slices.Values(a), slices.Values(b),
+			keyA, keyB, test.join))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("MergeJoin(%v,%v,%v)=%v, want %v",
+				a, b, test.join, got, test.want)
+		}
+	}
+}
+
+func TestMergeJoin_uneven(t *testing.T) {
+	a := []int{1, 2, 3}
+	b := []int{2}
+	id := func(i int) int { return i }
+	var got [][2][]int
+	for ga, gb := range MergeJoin(slices.Values(a), slices.Values(b),
+		id, id, OuterJoin) {
+		got = append(got, [2][]int{ga, gb})
+	}
+	want := [][2][]int{{{1}, nil}, {{2}, {2}}, {{3}, nil}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MergeJoin(%v,%v)=%v, want %v", a, b, got, want)
+	}
+
+	got = nil
+	for ga, gb := range MergeJoin(slices.Values(b), slices.Values(a),
+		id, id, OuterJoin) {
+		got = append(got, [2][]int{ga, gb})
+		break
+	}
+	want = [][2][]int{{nil, {1}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MergeJoin(%v,%v)=%v, want %v", b, a, got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package iterx
+
+import (
+	"fmt"
+	"iter"
+	"sync"
+)
+
+// Prefetch returns an iterator over the elements of it, that runs it on a
+// separate goroutine and reads up to n elements ahead of the consumer.
+// This lets an I/O bound producer and a CPU bound consumer run in parallel.
+//
+// When the consumer stops early, the producer is stopped before the
+// iteration returns. Panics in the producer are propagated to the consumer.
+func Prefetch[T any](it iter.Seq[T], n int) iter.Seq[T] {
+	if n < 0 {
+		panic(fmt.Sprintf("bad buffer size: %d", n))
+	}
+	return func(yield func(T) bool) {
+		ch := make(chan T, n)
+		done := make(chan struct{})
+		wg := &sync.WaitGroup{}
+		var p any // Producer's panic value
+		panicked := false
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(ch)
+			defer func() {
+				if r := recover(); r != nil {
+					p, panicked = r, true
+				}
+			}()
+			for x := range it {
+				// Check done first, since a select between a ready send
+				// and a closed channel is random.
+				select {
+				case <-done:
+					return
+				default:
+				}
+				select {
+				case ch <- x:
+				case <-done:
+					return
+				}
+			}
+		}()
+		defer func() {
+			close(done)
+			wg.Wait()
+		}()
+
+		for x := range ch {
+			if !yield(x) {
+				return
+			}
+		}
+		if panicked {
+			panic(p)
+		}
+	}
+}
+
+// Prefetch2 is like [Prefetch], for iterators over pairs, such as
+// elements and errors.
+func Prefetch2[T any, S any](it iter.Seq2[T, S], n int) iter.Seq2[T, S] {
+	type pair struct {
+		t T
+		s S
+	}
+	pairs := Prefetch(func(yield func(pair) bool) {
+		for t, s := range it {
+			if !yield(pair{t, s}) {
+				return
+			}
+		}
+	}, n)
+	return func(yield func(T, S) bool) {
+		for p := range pairs {
+			if !yield(p.t, p.s) {
+				return
+			}
+		}
+	}
+}
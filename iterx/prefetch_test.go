@@ -0,0 +1,80 @@
+package iterx
+
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPrefetch(t *testing.T) {
+	var input []int
+	for i := range 1000 {
+		input = append(input, i)
+	}
+	for _, n := range []int{0, 1, 10, 2000} {
+		got := slices.Collect(Prefetch(slices.Values(input), n))
+		if !slices.Equal(got, input) {
+			t.Fatalf("Prefetch(...,%d)=%v, want %v", n, got, input)
+		}
+	}
+}
+
+func TestPrefetch_break(t *testing.T) {
+	produced := &atomic.Int64{}
+	it := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			produced.Add(1)
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	n := 0
+	for range Prefetch(it, 5) {
+		n++
+		if n == 100 {
+			break
+		}
+	}
+	// Producer has returned, so the count is final.
+	if p := produced.Load(); p < 100 || p > 100+5+2 {
+		t.Fatalf("Prefetch(...) produced %d elements, want 100 to 107", p)
+	}
+}
+
+func TestPrefetch_panic(t *testing.T) {
+	it := func(yield func(int) bool) {
+		yield(1)
+		panic("oops")
+	}
+	defer func() {
+		if r := recover(); r != "oops" {
+			t.Fatalf("Prefetch(...) panicked with %v, want oops", r)
+		}
+	}()
+	for range Prefetch(it, 2) {
+	}
+	t.Fatalf("Prefetch(...) did not panic")
+}
+
+func TestPrefetch2(t *testing.T) {
+	bad := errors.New("bad")
+	it := func(yield func(int, error) bool) {
+		yield(1, nil)
+		yield(0, bad)
+		yield(2, nil)
+	}
+	var got []int
+	var errs []error
+	for x, err := range Prefetch2(it, 1) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, x)
+	}
+	if !slices.Equal(got, []int{1, 2}) || len(errs) != 1 || errs[0] != bad {
+		t.Fatalf("Prefetch2(...)=%v,%v, want [1 2],[%v]", got, errs, bad)
+	}
+}
@@ -0,0 +1,204 @@
+package iterx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/fluhus/gostuff/aio"
+	"github.com/fluhus/gostuff/heaps"
+	"github.com/fluhus/gostuff/ppln"
+)
+
+// SortOptions configures [ExternalSort].
+type SortOptions struct {
+	RunSize    int    // Max elements to sort in memory at once, default 1M
+	Goroutines int    // Number of runs to sort in parallel, default 1
+	Dir        string // Directory for temporary files, default os.TempDir
+}
+
+// ExternalSort returns an iterator over the elements of it, sorted by cmp.
+// The sort is stable.
+//
+// Elements are sorted in runs of up to RunSize elements, which are written
+// to temporary gzip-compressed files using encode, and then merged while
+// reading them using decode. Decode should return io.EOF at the end of
+// the input. For example, using package bnry:
+//
+//	encode := func(w io.Writer, a int64) error {
+//	  return bnry.Write(w, a)
+//	}
+//	decode := func(r *bufio.Reader) (int64, error) {
+//	  var a int64
+//	  err := bnry.Read(r, &a)
+//	  return a, err
+//	}
+//
+// Up to max(Goroutines,2)*RunSize elements are held in memory at once:
+// one run for each goroutine, and two runs while checking whether the
+// input fits in a single run.
+// If it has up to RunSize elements, they are sorted in memory without
+// temporary files.
+// Temporary files are removed when the iteration ends.
+func ExternalSort[T any](
+	it iter.Seq[T],
+	cmp func(a, b T) int,
+	encode func(w io.Writer, a T) error,
+	decode func(r *bufio.Reader) (T, error),
+	o SortOptions,
+) iter.Seq2[T, error] {
+	if o.RunSize == 0 {
+		o.RunSize = 1000000
+	}
+	if o.Goroutines == 0 {
+		o.Goroutines = 1
+	}
+	if o.RunSize < 1 {
+		panic(fmt.Sprintf("bad run size: %d", o.RunSize))
+	}
+	if o.Goroutines < 1 {
+		panic(fmt.Sprintf("bad number of goroutines: %d", o.Goroutines))
+	}
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		// Check if the input fits in a single run.
+		next, stop := iter.Pull(Chunk(it, o.RunSize))
+		defer stop()
+		first, ok := next()
+		if !ok {
+			return
+		}
+		second, ok := next()
+		if !ok {
+			slices.SortStableFunc(first, cmp)
+			for _, t := range first {
+				if !yield(t, nil) {
+					return
+				}
+			}
+			return
+		}
+
+		dir, err := os.MkdirTemp(o.Dir, "iterx-sort-*")
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer os.RemoveAll(dir)
+
+		// Drops the references to the first runs as they are handed out,
+		// so that they can be collected once written.
+		runs := func(yield func([]T, error) bool) {
+			for {
+				var run []T
+				switch {
+				case first != nil:
+					run, first = first, nil
+				case second != nil:
+					run, second = second, nil
+				default:
+					if run, ok = next(); !ok {
+						return
+					}
+				}
+				if !yield(run, nil) {
+					return
+				}
+			}
+		}
+		var files []string
+		err = ppln.Serial(o.Goroutines, runs,
+			func(a []T, i int, g int) (string, error) {
+				slices.SortStableFunc(a, cmp)
+				file := filepath.Join(dir, fmt.Sprintf("run%d.gz", i))
+				return file, writeRun(file, a, encode)
+			},
+			func(file string) error {
+				files = append(files, file)
+				return nil
+			})
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		for t, err := range mergeRuns(files, cmp, decode) {
+			if !yield(t, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Writes the given elements to a file.
+func writeRun[T any](file string, a []T, encode func(io.Writer, T) error) error {
+	f, err := aio.Create(file)
+	if err != nil {
+		return err
+	}
+	for _, t := range a {
+		if err := encode(f, t); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// Returns an iterator over the merged elements of the given sorted files.
+// Stops after the first error.
+func mergeRuns[T any](files []string, cmp func(a, b T) int,
+	decode func(r *bufio.Reader) (T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		var readers []*aio.Reader
+		defer func() {
+			for _, r := range readers {
+				r.Close()
+			}
+		}()
+		h := heaps.New(func(a, b mergeItem[T]) bool {
+			c := cmp(a.t, b.t)
+			return c < 0 || (c == 0 && a.i < b.i)
+		})
+		for i, file := range files {
+			r, err := aio.Open(file)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			readers = append(readers, r)
+			t, err := decode(&r.Reader)
+			if err == io.EOF {
+				continue
+			}
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			h.Push(mergeItem[T]{t, i})
+		}
+		for h.Len() > 0 {
+			item := h.Head()
+			if !yield(item.t, nil) {
+				return
+			}
+			t, err := decode(&readers[item.i].Reader)
+			if err == io.EOF {
+				h.Pop()
+				continue
+			}
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			h.View()[0].t = t
+			h.Fix(0)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package iterx
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/fluhus/gostuff/bnry"
+)
+
+type sortItem struct {
+	Key int64
+	Val int64 // Original position, for checking stability
+}
+
+func encodeSortItem(w io.Writer, a sortItem) error {
+	return bnry.Write(w, a.Key, a.Val)
+}
+
+func decodeSortItem(r *bufio.Reader) (sortItem, error) {
+	var a sortItem
+	err := bnry.Read(r, &a.Key, &a.Val)
+	return a, err
+}
+
+func cmpSortItem(a, b sortItem) int {
+	return cmp.Compare(a.Key, b.Key)
+}
+
+func TestExternalSort(t *testing.T) {
+	var input []sortItem
+	for i := range 10000 {
+		input = append(input, sortItem{rand.Int64N(1000), int64(i)})
+	}
+	want := slices.Clone(input)
+	slices.SortStableFunc(want, cmpSortItem)
+
+	for _, o := range []SortOptions{
+		{},
+		{RunSize: 10000},
+		{RunSize: 9999},
+		{RunSize: 777},
+		{RunSize: 500, Goroutines: 4},
+		{RunSize: 50, Goroutines: 2},
+	} {
+		o.Dir = t.TempDir()
+		got, err := CollectErr(ExternalSort(slices.Values(input),
+			cmpSortItem, encodeSortItem, decodeSortItem, o))
+		if err != nil {
+			t.Fatalf("ExternalSort(%+v) failed: %v", o, err)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("ExternalSort(%+v) is not sorted", o)
+		}
+		entries, err := os.ReadDir(o.Dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("ExternalSort(%+v) left %d files", o, len(entries))
+		}
+	}
+}
+
+func TestExternalSort_empty(t *testing.T) {
+	got, err := CollectErr(ExternalSort(slices.Values([]sortItem(nil)),
+		cmpSortItem, encodeSortItem, decodeSortItem, SortOptions{}))
+	if err != nil || len(got) != 0 {
+		t.Fatalf("ExternalSort(nil)=%v,%v, want [],nil", got, err)
+	}
+}
+
+func TestExternalSort_break(t *testing.T) {
+	var input []sortItem
+	for i := range 1000 {
+		input = append(input, sortItem{int64(1000 - i), int64(i)})
+	}
+	o := SortOptions{RunSize: 100, Dir: t.TempDir()}
+	n := 0
+	for x, err := range ExternalSort(slices.Values(input),
+		cmpSortItem, encodeSortItem, decodeSortItem, o) {
+		if err != nil {
+			t.Fatalf("ExternalSort(...) failed: %v", err)
+		}
+		n++
+		if x.Key != int64(n) {
+			t.Fatalf("ExternalSort(...)[%d]=%v, want key %d", n-1, x, n)
+		}
+		if n == 150 {
+			break
+		}
+	}
+	entries, err := os.ReadDir(o.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("ExternalSort(...) left %d files", len(entries))
+	}
+}
+
+func TestExternalSort_error(t *testing.T) {
+	input := []sortItem{{3, 0}, {2, 1}, {1, 2}}
+	bad := errors.New("bad")
+	encode := func(w io.Writer, a sortItem) error {
+		if a.Key == 2 {
+			return bad
+		}
+		return encodeSortItem(w, a)
+	}
+	_, err := CollectErr(ExternalSort(slices.Values(input), cmpSortItem,
+		encode, decodeSortItem, SortOptions{RunSize: 1, Dir: t.TempDir()}))
+	if !errors.Is(err, bad) {
+		t.Fatalf("ExternalSort(...) error=%v, want %v", err, bad)
+	}
+}
@@ -0,0 +1,267 @@
+package jio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Index provides random access to the values of a JSON Lines file by key.
+//
+// The file may be uncompressed, or compressed with BGZF-style gzip
+// (suffix .gz), where the file is a series of gzip members of up to 64KiB
+// of uncompressed data each, as produced by bgzip.
+// Offsets in compressed files are virtual: the member's offset in the file
+// shifted 16 bits left, plus the offset within the member's data.
+type Index[T any, K comparable] struct {
+	f       *os.File
+	gz      bool
+	offsets map[K]int64
+}
+
+// BuildIndex reads a JSON Lines file and maps each value's key,
+// as returned by the key function, to the value's offset.
+// Keys must be unique.
+//
+// The returned index should be closed after use.
+func BuildIndex[T any, K comparable](file string, key func(T) K) (
+	*Index[T, K], error) {
+	gz, err := isGzip(file)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index[T, K]{f: f, gz: gz, offsets: map[K]int64{}}
+	add := func(line []byte, n int, offset int64) error {
+		var t T
+		if err := json.Unmarshal(line, &t); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		k := key(t)
+		if _, ok := idx.offsets[k]; ok {
+			return fmt.Errorf("line %d: duplicate key: %v", n, k)
+		}
+		idx.offsets[k] = offset
+		return nil
+	}
+	if gz {
+		err = scanBGZF(f, add)
+	} else {
+		err = scanLines(f, add)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return idx, nil
+}
+
+// LoadIndex opens a JSON Lines file using an index that was saved with
+// [Index.Save].
+//
+// The returned index should be closed after use.
+func LoadIndex[T any, K comparable](file, indexFile string) (
+	*Index[T, K], error) {
+	gz, err := isGzip(file)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := ReadAs[map[K]int64](indexFile)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	return &Index[T, K]{f: f, gz: gz, offsets: offsets}, nil
+}
+
+// Save writes the index's offsets to a file, for loading with [LoadIndex].
+// Keys must be encodable as JSON object keys.
+func (idx *Index[T, K]) Save(file string) error {
+	return WriteWith(file, idx.offsets, WriteOptions{Compact: true})
+}
+
+// Len returns the number of keys in the index.
+func (idx *Index[T, K]) Len() int {
+	return len(idx.offsets)
+}
+
+// Offset returns the offset of the value with the given key.
+func (idx *Index[T, K]) Offset(k K) (int64, bool) {
+	o, ok := idx.offsets[k]
+	return o, ok
+}
+
+// Get reads and decodes the value with the given key.
+// Returns false if the key is not in the index.
+// Safe for concurrent use.
+func (idx *Index[T, K]) Get(k K) (T, bool, error) {
+	var t T
+	o, ok := idx.offsets[k]
+	if !ok {
+		return t, false, nil
+	}
+	line, err := idx.line(o)
+	if err != nil {
+		return t, true, err
+	}
+	if err := json.Unmarshal(line, &t); err != nil {
+		return t, true, err
+	}
+	return t, true, nil
+}
+
+// Close closes the underlying file.
+func (idx *Index[T, K]) Close() error {
+	return idx.f.Close()
+}
+
+// Returns the line at the given offset.
+func (idx *Index[T, K]) line(o int64) ([]byte, error) {
+	if !idx.gz {
+		r := bufio.NewReader(io.NewSectionReader(idx.f, o, 1<<62))
+		return readLine(r)
+	}
+	z, err := gzip.NewReader(io.NewSectionReader(idx.f, o>>16, 1<<62))
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(z)
+	if _, err := r.Discard(int(o & 0xffff)); err != nil {
+		return nil, err
+	}
+	return readLine(r)
+}
+
+// Reads a line, allowing a missing newline at the end of the input.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	return line, err
+}
+
+// Checks whether the given file should be treated as BGZF,
+// by its suffix.
+func isGzip(file string) (bool, error) {
+	switch filepath.Ext(file) {
+	case ".gz":
+		return true, nil
+	case ".zst", ".bz2":
+		return false, fmt.Errorf("unsupported compression for indexing: %v",
+			filepath.Ext(file))
+	}
+	return false, nil
+}
+
+// Calls fn on each non-blank line of an uncompressed file,
+// with its 1-based line number and byte offset.
+func scanLines(r io.Reader, fn func(line []byte, n int, offset int64) error) error {
+	br := bufio.NewReader(r)
+	var offset int64
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err := fn(line, n, offset); err != nil {
+				return err
+			}
+		}
+		offset += int64(len(line))
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Calls fn on each non-blank line of a BGZF file,
+// with its 1-based line number and virtual offset.
+func scanBGZF(r io.Reader, fn func(line []byte, n int, offset int64) error) error {
+	cr := &countingReader{r: bufio.NewReader(r)}
+	z, err := gzip.NewReader(cr)
+	if err != nil {
+		return err
+	}
+	z.Multistream(false)
+
+	var line []byte   // Current line, may span members
+	var lineOff int64 // Virtual offset of the current line
+	n := 1
+	flush := func() error {
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err := fn(line, n, lineOff); err != nil {
+				return err
+			}
+		}
+		line = nil
+		n++
+		return nil
+	}
+	var start int64 // Offset of the current member
+	for {
+		data, err := io.ReadAll(z)
+		if err != nil {
+			return err
+		}
+		if len(data) > 1<<16 {
+			return fmt.Errorf("gzip member at offset %d is too large for "+
+				"indexing: %d bytes, max %d", start, len(data), 1<<16)
+		}
+		for i := 0; i < len(data); {
+			if line == nil {
+				lineOff = start<<16 | int64(i)
+			}
+			j := bytes.IndexByte(data[i:], '\n')
+			if j == -1 {
+				line = append(line, data[i:]...)
+				break
+			}
+			line = append(line, data[i:i+j+1]...)
+			if err := flush(); err != nil {
+				return err
+			}
+			i += j + 1
+		}
+		start = cr.n
+		if err := z.Reset(cr); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		z.Multistream(false)
+	}
+	return flush()
+}
+
+// A byte reader that counts the bytes read from it.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func (r *countingReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.n++
+	}
+	return b, err
+}
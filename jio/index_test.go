@@ -0,0 +1,110 @@
+package jio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Returns the lines of a JSON Lines file of writerItems.
+func indexTestData(n int) []byte {
+	buf := &bytes.Buffer{}
+	for i := range n {
+		fmt.Fprintf(buf, "{\"A\":%d,\"B\":\"%s\"}\n", i, bytes.Repeat([]byte("x"), i%50))
+		if i%7 == 0 {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+// Compresses data as gzip members of up to size bytes each.
+func bgzf(data []byte, size int) []byte {
+	buf := &bytes.Buffer{}
+	for len(data) > 0 {
+		m := min(size, len(data))
+		z := gzip.NewWriter(buf)
+		z.Write(data[:m])
+		z.Close()
+		data = data[m:]
+	}
+	z := gzip.NewWriter(buf) // Empty end-of-file member, like in bgzip.
+	z.Close()
+	return buf.Bytes()
+}
+
+func TestIndex(t *testing.T) {
+	data := indexTestData(500)
+	files := map[string][]byte{
+		"a.json":    data,
+		"a.json.gz": bgzf(data, 1000),
+	}
+	for name, b := range files {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, name)
+			if err := os.WriteFile(file, b, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			idx, err := BuildIndex(file, func(x writerItem) int { return x.A })
+			if err != nil {
+				t.Fatalf("BuildIndex(%q) failed: %v", file, err)
+			}
+			defer idx.Close()
+			if idx.Len() != 500 {
+				t.Fatalf("BuildIndex(%q).Len()=%d, want 500", file, idx.Len())
+			}
+			check := func(idx *Index[writerItem, int]) {
+				for _, i := range []int{499, 0, 137, 7, 8, 250} {
+					got, ok, err := idx.Get(i)
+					if err != nil || !ok {
+						t.Fatalf("Get(%d) failed: %v, %v", i, ok, err)
+					}
+					want := writerItem{i, string(bytes.Repeat([]byte("x"), i%50))}
+					if got != want {
+						t.Fatalf("Get(%d)=%v, want %v", i, got, want)
+					}
+				}
+				if _, ok, err := idx.Get(500); ok || err != nil {
+					t.Fatalf("Get(500)=%v, %v, want false, nil", ok, err)
+				}
+			}
+			check(idx)
+
+			indexFile := filepath.Join(dir, "index.json")
+			if err := idx.Save(indexFile); err != nil {
+				t.Fatalf("Save(%q) failed: %v", indexFile, err)
+			}
+			loaded, err := LoadIndex[writerItem, int](file, indexFile)
+			if err != nil {
+				t.Fatalf("LoadIndex(%q) failed: %v", indexFile, err)
+			}
+			defer loaded.Close()
+			check(loaded)
+		})
+	}
+}
+
+func TestBuildIndex_bad(t *testing.T) {
+	dir := t.TempDir()
+	key := func(x writerItem) int { return x.A }
+	tests := map[string][]byte{
+		"dup.json":      []byte("{\"A\":1,\"B\":\"a\"}\n{\"A\":1,\"B\":\"b\"}\n"),
+		"syntax.json":   []byte("{\"A\":1,\"B\":\"a\"}\n{\"A\":\n"),
+		"large.json.gz": bgzf(indexTestData(2000), 1<<17),
+		"a.json.zst":    nil,
+	}
+	for name, b := range tests {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if idx, err := BuildIndex(file, key); err == nil {
+			idx.Close()
+			t.Errorf("BuildIndex(%q) succeeded, want error", name)
+		}
+	}
+}
@@ -7,18 +7,41 @@ package jio
 import (
 	"encoding/json"
 	"io"
+	"os"
+	"path/filepath"
 
 	"github.com/fluhus/gostuff/aio"
 )
 
-// Write saves v to the given file, encoded as JSON.
+// Write saves v to the given file, encoded as indented JSON.
 func Write(file string, v any) error {
+	return WriteWith(file, v, WriteOptions{})
+}
+
+// WriteOptions configures [WriteWith].
+type WriteOptions struct {
+	Compact bool // Write without indentation
+	Atomic  bool // Write to a temporary file and rename it to the target
+}
+
+// WriteWith saves v to the given file, encoded as JSON,
+// according to the given options.
+//
+// Atomic writes leave the file either untouched or fully written,
+// even if the program crashes mid-write. An existing file's permissions
+// are kept.
+func WriteWith(file string, v any, o WriteOptions) error {
+	if o.Atomic {
+		return writeAtomic(file, v, o)
+	}
 	f, err := aio.Create(file)
 	if err != nil {
 		return err
 	}
 	e := json.NewEncoder(f)
-	e.SetIndent("", "  ")
+	if !o.Compact {
+		e.SetIndent("", "  ")
+	}
 	if err := e.Encode(v); err != nil {
 		f.Close()
 		return err
@@ -26,14 +49,85 @@ func Write(file string, v any) error {
 	return f.Close()
 }
 
+// Writes v to a temporary file in the target's directory, then renames it
+// to the target. Keeps the target's permissions if it exists.
+func writeAtomic(file string, v any, o WriteOptions) error {
+	// Keep the suffix for compression.
+	dir, base := filepath.Split(file)
+	if dir == "" { // Otherwise CreateTemp uses the default temp directory.
+		dir = "."
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".*"+filepath.Ext(file))
+	if err != nil {
+		return err
+	}
+	tmpFile := tmp.Name()
+	tmp.Close()
+
+	o.Atomic = false
+	if err := WriteWith(tmpFile, v, o); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	if err := syncFile(tmpFile); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	if err := os.Chmod(tmpFile, mode); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	if err := os.Rename(tmpFile, file); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return nil
+}
+
+// Flushes the file's content to disk.
+func syncFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // Read loads a JSON encoded value from the given file and populates v with it.
 func Read(file string, v any) error {
+	return ReadWith(file, v, ReadOptions{})
+}
+
+// ReadOptions configures [ReadWith].
+type ReadOptions struct {
+	DisallowUnknownFields bool // Fail on object keys that do not match v
+	UseNumber             bool // Decode numbers in interfaces as json.Number
+}
+
+// ReadWith loads a JSON encoded value from the given file and populates v
+// with it, according to the given options.
+func ReadWith(file string, v any, o ReadOptions) error {
 	f, err := aio.Open(file)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	return json.NewDecoder(f).Decode(v)
+	d := json.NewDecoder(f)
+	if o.DisallowUnknownFields {
+		d.DisallowUnknownFields()
+	}
+	if o.UseNumber {
+		d.UseNumber()
+	}
+	return d.Decode(v)
 }
 
 // ReadAs reads a JSON encoded value of type T and returns it.
@@ -0,0 +1,156 @@
+package jio
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteWith(t *testing.T) {
+	v := writerItem{1, "a"}
+	tests := []struct {
+		o    WriteOptions
+		want string
+	}{
+		{WriteOptions{}, "{\n  \"A\": 1,\n  \"B\": \"a\"\n}\n"},
+		{WriteOptions{Compact: true}, "{\"A\":1,\"B\":\"a\"}\n"},
+		{WriteOptions{Compact: true, Atomic: true}, "{\"A\":1,\"B\":\"a\"}\n"},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "a.json")
+		if err := os.WriteFile(file, []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteWith(file, v, test.o); err != nil {
+			t.Fatalf("WriteWith(%+v) failed: %v", test.o, err)
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b); got != test.want {
+			t.Fatalf("WriteWith(%+v)=%q, want %q", test.o, got, test.want)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("WriteWith(%+v) left %d files, want 1", test.o, len(entries))
+		}
+	}
+}
+
+func TestWriteWith_atomicCompressed(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.json.gz")
+	want := writerItem{1, "a"}
+	if err := WriteWith(file, want, WriteOptions{Atomic: true}); err != nil {
+		t.Fatalf("WriteWith(%q) failed: %v", file, err)
+	}
+	got, err := ReadAs[writerItem](file)
+	if err != nil {
+		t.Fatalf("ReadAs(%q) failed: %v", file, err)
+	}
+	if got != want {
+		t.Fatalf("ReadAs(%q)=%v, want %v", file, got, want)
+	}
+}
+
+func TestWriteWith_atomicError(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.json")
+	if err := os.WriteFile(file, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteWith(file, func() {}, WriteOptions{Atomic: true}); err == nil {
+		t.Fatalf("WriteWith(func) succeeded, want error")
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "old" {
+		t.Fatalf("WriteWith(func) changed file to %q, want %q", b, "old")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("WriteWith(func) left %d files, want 1", len(entries))
+	}
+}
+
+func TestWriteWith_atomicRelative(t *testing.T) {
+	t.Chdir(t.TempDir())
+	// Temporary files should not be created in the default directory.
+	t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+	want := writerItem{1, "a"}
+	if err := WriteWith("a.json", want, WriteOptions{Atomic: true}); err != nil {
+		t.Fatalf("WriteWith(%q) failed: %v", "a.json", err)
+	}
+	got, err := ReadAs[writerItem]("a.json")
+	if err != nil {
+		t.Fatalf("ReadAs(%q) failed: %v", "a.json", err)
+	}
+	if got != want {
+		t.Fatalf("ReadAs(%q)=%v, want %v", "a.json", got, want)
+	}
+}
+
+func TestWriteWith_atomicMode(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.json")
+	if err := os.WriteFile(file, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteWith(file, 1, WriteOptions{Atomic: true}); err != nil {
+		t.Fatalf("WriteWith(%q) failed: %v", file, err)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("WriteWith(%q) mode=%v, want %v",
+			file, info.Mode().Perm(), os.FileMode(0o600))
+	}
+}
+
+func TestReadWith(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.json")
+	if err := os.WriteFile(
+		file, []byte(`{"A":12345678901234567890,"C":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var item writerItem
+	if err := ReadWith(file, &item, ReadOptions{}); err == nil {
+		t.Fatalf("ReadWith(%q) succeeded, want overflow error", file)
+	}
+	var m map[string]any
+	if err := ReadWith(file, &m, ReadOptions{UseNumber: true}); err != nil {
+		t.Fatalf("ReadWith(%q) failed: %v", file, err)
+	}
+	want := map[string]any{
+		"A": json.Number("12345678901234567890"),
+		"C": json.Number("1"),
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("ReadWith(%q)=%v, want %v", file, m, want)
+	}
+
+	if err := os.WriteFile(file, []byte(`{"A":1,"C":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadWith(file, &item, ReadOptions{}); err != nil {
+		t.Fatalf("ReadWith(%q) failed: %v", file, err)
+	}
+	err := ReadWith(file, &item, ReadOptions{DisallowUnknownFields: true})
+	if err == nil {
+		t.Fatalf("ReadWith(%q, DisallowUnknownFields) succeeded, want error",
+			file)
+	}
+}
@@ -0,0 +1,130 @@
+package jio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/fluhus/gostuff/aio"
+	"github.com/fluhus/gostuff/ppln"
+)
+
+// Number of lines that are decoded together on a single goroutine.
+const parallelBatchSize = 1000
+
+// IterParallel is like [Iter], but for JSON Lines files, where each
+// value is on its own line.
+// Lines are decoded on ngoroutines goroutines and yielded in their file
+// order. Blank lines are skipped.
+// Decoding errors include the 1-based line number, and stop the iteration.
+func IterParallel[T any](file string, ngoroutines int) iter.Seq2[T, error] {
+	return iterParallel[T](func() (io.ReadCloser, error) {
+		return aio.Open(file)
+	}, ngoroutines)
+}
+
+// IterParallelReader is like [IterParallel], but reads JSON Lines from r.
+// The reader is consumed by the iteration, so it can be iterated once.
+func IterParallelReader[T any](r io.Reader, ngoroutines int) iter.Seq2[T, error] {
+	return iterParallel[T](func() (io.ReadCloser, error) {
+		return io.NopCloser(r), nil
+	}, ngoroutines)
+}
+
+// Implements the parallel iteration over the lines of the reader that
+// open returns.
+func iterParallel[T any](open func() (io.ReadCloser, error),
+	ngoroutines int) iter.Seq2[T, error] {
+	if ngoroutines < 1 {
+		panic(fmt.Sprintf("bad number of goroutines: %d", ngoroutines))
+	}
+	return func(yield func(T, error) bool) {
+		results := ppln.SerialIter(
+			ngoroutines,
+			lineBatches(open),
+			func(a []jsonLine, i, g int) ([]lineResult[T], error) {
+				result := make([]lineResult[T], len(a))
+				for j, line := range a {
+					if line.err != nil {
+						result[j].err = line.err
+						continue
+					}
+					if err := json.Unmarshal(line.b, &result[j].t); err != nil {
+						result[j].err = fmt.Errorf("line %d: %w", line.n, err)
+					}
+				}
+				return result, nil
+			})
+
+		var zero T
+		for batch, err := range results {
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, res := range batch {
+				if res.err != nil {
+					yield(zero, res.err)
+					return
+				}
+				if !yield(res.t, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// A line of JSON or an error, with its 1-based line number.
+type jsonLine struct {
+	b   []byte
+	n   int
+	err error
+}
+
+// A decoded value or an error.
+type lineResult[T any] struct {
+	t   T
+	err error
+}
+
+// Iterates over batches of non-blank lines from the reader that open
+// returns. Errors are passed along as lines, so that the pipeline outputs
+// the lines before them.
+func lineBatches(open func() (io.ReadCloser, error)) iter.Seq2[[]jsonLine, error] {
+	return func(yield func([]jsonLine, error) bool) {
+		rc, err := open()
+		if err != nil {
+			yield([]jsonLine{{err: err}}, nil)
+			return
+		}
+		defer rc.Close()
+		f := bufio.NewReader(rc)
+
+		var batch []jsonLine
+		for n := 1; ; n++ {
+			b, err := f.ReadBytes('\n')
+			if len(bytes.TrimSpace(b)) > 0 {
+				batch = append(batch, jsonLine{b: b, n: n})
+			}
+			if err != nil {
+				if err != io.EOF {
+					batch = append(batch, jsonLine{err: err})
+				}
+				break
+			}
+			if len(batch) == parallelBatchSize {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = nil
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch, nil)
+		}
+	}
+}
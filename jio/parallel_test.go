@@ -0,0 +1,111 @@
+package jio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIterParallel(t *testing.T) {
+	for _, suffix := range []string{".json", ".json.gz"} {
+		t.Run(suffix, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "a"+suffix)
+			w, err := Create(file)
+			if err != nil {
+				t.Fatalf("Create(%q) failed: %v", file, err)
+			}
+			for i := range 2500 {
+				if err := w.Write(writerItem{i, "x"}); err != nil {
+					t.Fatalf("Write(%v) failed: %v", i, err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() failed: %v", err)
+			}
+			for _, ngoroutines := range []int{1, 4} {
+				i := 0
+				for x, err := range IterParallel[writerItem](file, ngoroutines) {
+					if err != nil {
+						t.Fatalf("IterParallel(%q) failed: %v", file, err)
+					}
+					if x != (writerItem{i, "x"}) {
+						t.Fatalf("IterParallel(%q)[%d]=%v, want %v",
+							file, i, x, writerItem{i, "x"})
+					}
+					i++
+				}
+				if i != 2500 {
+					t.Fatalf("IterParallel(%q) yielded %d values, want 2500",
+						file, i)
+				}
+			}
+		})
+	}
+}
+
+func TestIterParallel_error(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.json")
+	input := "{\"A\":1}\n\n{\"A\":2}\n{\"A\":\n{\"A\":4}\n"
+	if err := os.WriteFile(file, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	var gotErr error
+	for x, err := range IterParallel[writerItem](file, 2) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		got = append(got, x.A)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("IterParallel(%q)=%v, want [1 2]", input, got)
+	}
+	if gotErr == nil || !strings.HasPrefix(gotErr.Error(), "line 4:") {
+		t.Fatalf("IterParallel(%q) error=%v, want error on line 4", input, gotErr)
+	}
+}
+
+func TestIterParallel_break(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.json")
+	w, err := Create(file)
+	if err != nil {
+		t.Fatalf("Create(%q) failed: %v", file, err)
+	}
+	for i := range 5000 {
+		w.Write(writerItem{A: i})
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	n := 0
+	for range IterParallel[writerItem](file, 4) {
+		n++
+		if n == 1500 {
+			break
+		}
+	}
+}
+
+func TestIterParallelReader(t *testing.T) {
+	input := &strings.Builder{}
+	for i := range 2500 {
+		fmt.Fprintf(input, "{\"A\":%d}\n", i)
+	}
+	i := 0
+	for x, err := range IterParallelReader[writerItem](
+		strings.NewReader(input.String()), 4) {
+		if err != nil {
+			t.Fatalf("IterParallelReader(...) failed: %v", err)
+		}
+		if x.A != i {
+			t.Fatalf("IterParallelReader(...)[%d]=%v, want %v", i, x.A, i)
+		}
+		i++
+	}
+	if i != 2500 {
+		t.Fatalf("IterParallelReader(...) yielded %d values, want 2500", i)
+	}
+}
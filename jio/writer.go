@@ -0,0 +1,61 @@
+package jio
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/fluhus/gostuff/aio"
+)
+
+// Writer writes values to a file in JSON Lines format,
+// one compact value per line.
+// The output can be read with [Iter].
+//
+// A Writer is safe for concurrent use, for example from a ppln output
+// function.
+type Writer struct {
+	mu sync.Mutex
+	w  *aio.Writer
+	e  *json.Encoder
+}
+
+// Create opens a file for writing JSON Lines.
+// Erases any previously existing content.
+// Compresses the data according to the file's suffix.
+func Create(file string) (*Writer, error) {
+	f, err := aio.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(f), nil
+}
+
+// Append opens a file for writing JSON Lines.
+// Appends to previously existing content if any.
+// Compresses the data according to the file's suffix.
+func Append(file string) (*Writer, error) {
+	f, err := aio.Append(file)
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(f), nil
+}
+
+// Returns a writer that encodes values to f.
+func newWriter(f *aio.Writer) *Writer {
+	return &Writer{w: f, e: json.NewEncoder(f)}
+}
+
+// Write encodes v as a single line.
+func (w *Writer) Write(v any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.e.Encode(v)
+}
+
+// Close flushes the written data and closes the file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Close()
+}
@@ -0,0 +1,100 @@
+package jio
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fluhus/gostuff/iterx"
+)
+
+type writerItem struct {
+	A int
+	B string
+}
+
+func TestWriter(t *testing.T) {
+	for _, suffix := range []string{".json", ".json.gz"} {
+		t.Run(suffix, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "a"+suffix)
+			want := []writerItem{{1, "a"}, {2, "b"}, {3, "c"}}
+
+			w, err := Create(file)
+			if err != nil {
+				t.Fatalf("Create(%q) failed: %v", file, err)
+			}
+			for _, x := range want[:2] {
+				if err := w.Write(x); err != nil {
+					t.Fatalf("Write(%v) failed: %v", x, err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() failed: %v", err)
+			}
+
+			w, err = Append(file)
+			if err != nil {
+				t.Fatalf("Append(%q) failed: %v", file, err)
+			}
+			if err := w.Write(want[2]); err != nil {
+				t.Fatalf("Write(%v) failed: %v", want[2], err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() failed: %v", err)
+			}
+
+			got, err := iterx.CollectErr(Iter[writerItem](file))
+			if err != nil {
+				t.Fatalf("Iter(%q) failed: %v", file, err)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("Iter(%q)=%v, want %v", file, got, want)
+			}
+		})
+	}
+}
+
+func TestWriter_lines(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.json")
+	w, err := Create(file)
+	if err != nil {
+		t.Fatalf("Create(%q) failed: %v", file, err)
+	}
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Go(func() {
+			if err := w.Write(writerItem{i, "x"}); err != nil {
+				t.Errorf("Write(%v) failed: %v", i, err)
+			}
+		})
+	}
+	wg.Wait()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := iterx.CollectErr(iterx.LinesFile(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 100 {
+		t.Fatalf("Create(%q) wrote %d lines, want 100:\n%s",
+			file, len(lines), b)
+	}
+	var sum int
+	for x, err := range Iter[writerItem](file) {
+		if err != nil {
+			t.Fatalf("Iter(%q) failed: %v", file, err)
+		}
+		sum += x.A
+	}
+	if sum != 4950 {
+		t.Fatalf("Iter(%q) sum=%d, want 4950", file, sum)
+	}
+}
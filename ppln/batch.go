@@ -0,0 +1,123 @@
+package ppln
+
+import (
+	"fmt"
+	"iter"
+)
+
+// SerialBatch is like [Serial], but pulls inputs in batches of up to
+// batchSize items, transforms each batch on a single goroutine and
+// outputs whole batches at a time.
+// This reduces the locking overhead per item, which can dominate when
+// transform is cheap.
+//
+// Transform is called on each item separately, with i being the item's
+// 0-based serial number (not the batch's).
+// Outputs are ordered in the same order of the inputs.
+// A panic in transform is returned as a [*PanicError] with the item's
+// serial number.
+func SerialBatch[T1 any, T2 any](
+	ngoroutines int,
+	batchSize int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, i int, g int) (T2, error),
+	output func(a T2) error) error {
+	if batchSize < 1 {
+		panic(fmt.Sprintf("bad batch size: %d", batchSize))
+	}
+	return Serial(
+		ngoroutines,
+		batchInput(input, batchSize),
+		func(a []T1, i int, g int) ([]T2, error) {
+			result := make([]T2, len(a))
+			for j, t1 := range a {
+				t2, err := safeTransform(transform, t1, i*batchSize+j, g)
+				if err != nil {
+					return nil, err
+				}
+				result[j] = t2
+			}
+			return result, nil
+		},
+		flatOutput(output))
+}
+
+// NonSerialBatch is like [NonSerial], but pulls inputs in batches of up to
+// batchSize items, transforms each batch on a single goroutine and
+// outputs whole batches at a time.
+// This reduces the locking overhead per item, which can dominate when
+// transform is cheap.
+//
+// Items within a batch are outputted in their input order,
+// while the order of batches is arbitrary.
+// A panic in transform is returned as a [*PanicError] with the item's
+// serial number.
+func NonSerialBatch[T1 any, T2 any](
+	ngoroutines int,
+	batchSize int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, g int) (T2, error),
+	output func(a T2) error) error {
+	if batchSize < 1 {
+		panic(fmt.Sprintf("bad batch size: %d", batchSize))
+	}
+	// Ignores the serial number, which is only used for reporting panics.
+	itransform := func(a T1, i int, g int) (T2, error) {
+		return transform(a, g)
+	}
+	return NonSerial(
+		ngoroutines,
+		numberedBatchInput(input, batchSize),
+		func(a serialItem[[]T1], g int) ([]T2, error) {
+			result := make([]T2, len(a.data))
+			for j, t1 := range a.data {
+				t2, err := safeTransform(itransform, t1, a.i+j, g)
+				if err != nil {
+					return nil, err
+				}
+				result[j] = t2
+			}
+			return result, nil
+		},
+		flatOutput(output))
+}
+
+// Groups the values of an input iterator into slices of up to n elements.
+// An error is yielded as soon as it is encountered,
+// discarding the partial batch.
+func batchInput[T any](input iter.Seq2[T, error], n int) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		batch := make([]T, 0, n)
+		for t, err := range input {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			batch = append(batch, t)
+			if len(batch) == n {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = make([]T, 0, n)
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch, nil)
+		}
+	}
+}
+
+// Like batchInput, but attaches to each batch the serial number of its
+// first item.
+func numberedBatchInput[T any](input iter.Seq2[T, error], n int,
+) iter.Seq2[serialItem[[]T], error] {
+	return func(yield func(serialItem[[]T], error) bool) {
+		i := 0
+		for batch, err := range batchInput(input, n) {
+			if !yield(serialItem[[]T]{i, batch}, err) {
+				return
+			}
+			i += len(batch)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package ppln
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestSerialBatch(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		for _, bs := range []int{1, 3, 10, 1000} {
+			t.Run(fmt.Sprint(nt, bs), func(t *testing.T) {
+				n := 1000
+				var result, is []int
+				err := SerialBatch(
+					nt,
+					bs,
+					RangeInput(0, n),
+					func(a int, i int, g int) ([2]int, error) {
+						return [2]int{a * a, i}, nil
+					},
+					func(a [2]int) error {
+						result = append(result, a[0])
+						is = append(is, a[1])
+						return nil
+					})
+				if err != nil {
+					t.Fatalf("SerialBatch(...) failed: %v", err)
+				}
+				if len(result) != n {
+					t.Fatalf("SerialBatch(...) len=%d, want %d",
+						len(result), n)
+				}
+				for i := range result {
+					if result[i] != i*i {
+						t.Errorf("result[%d]=%d, want %d", i, result[i], i*i)
+					}
+					if is[i] != i {
+						t.Errorf("i[%d]=%d, want %d", i, is[i], i)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSerialBatch_error(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			err := SerialBatch(
+				nt,
+				7,
+				RangeInput(0, 1000),
+				func(a int, i int, g int) (int, error) {
+					if a == 500 {
+						return 0, fmt.Errorf("oh no")
+					}
+					return a, nil
+				},
+				func(a int) error {
+					return nil
+				})
+			if err == nil {
+				t.Fatalf("SerialBatch(...) succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestNonSerialBatch(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		for _, bs := range []int{1, 3, 10, 1000} {
+			t.Run(fmt.Sprint(nt, bs), func(t *testing.T) {
+				n := 1000
+				var result []int
+				err := NonSerialBatch(
+					nt,
+					bs,
+					RangeInput(0, n),
+					func(a int, g int) (int, error) {
+						return a * a, nil
+					},
+					func(a int) error {
+						result = append(result, a)
+						return nil
+					})
+				if err != nil {
+					t.Fatalf("NonSerialBatch(...) failed: %v", err)
+				}
+				slices.Sort(result)
+				if len(result) != n {
+					t.Fatalf("NonSerialBatch(...) len=%d, want %d",
+						len(result), n)
+				}
+				for i := range result {
+					if result[i] != i*i {
+						t.Errorf("result[%d]=%d, want %d", i, result[i], i*i)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestBatchInput(t *testing.T) {
+	var got [][]int
+	for b, err := range batchInput(RangeInput(0, 7), 3) {
+		if err != nil {
+			t.Fatalf("batchInput(...) failed: %v", err)
+		}
+		got = append(got, b)
+	}
+	want := [][]int{{0, 1, 2}, {3, 4, 5}, {6}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Fatalf("batchInput(...)=%v, want %v", got, want)
+	}
+}
+
+func BenchmarkSerial(b *testing.B) {
+	input := make([]string, 10000)
+	for i := range input {
+		input[i] = strconv.Itoa(i)
+	}
+	b.Run("item", func(b *testing.B) {
+		for b.Loop() {
+			Serial(4, SliceInput(input),
+				func(a string, i int, g int) (int, error) {
+					return strconv.Atoi(a)
+				},
+				func(a int) error { return nil })
+		}
+	})
+	for _, bs := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprint("batch", bs), func(b *testing.B) {
+			for b.Loop() {
+				SerialBatch(4, bs, SliceInput(input),
+					func(a string, i int, g int) (int, error) {
+						return strconv.Atoi(a)
+					},
+					func(a int) error { return nil })
+			}
+		})
+	}
+}
+
+func BenchmarkNonSerial(b *testing.B) {
+	input := make([]string, 10000)
+	for i := range input {
+		input[i] = strconv.Itoa(i)
+	}
+	b.Run("item", func(b *testing.B) {
+		for b.Loop() {
+			NonSerial(4, SliceInput(input),
+				func(a string, g int) (int, error) {
+					return strconv.Atoi(a)
+				},
+				func(a int) error { return nil })
+		}
+	})
+	for _, bs := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprint("batch", bs), func(b *testing.B) {
+			for b.Loop() {
+				NonSerialBatch(4, bs, SliceInput(input),
+					func(a string, g int) (int, error) {
+						return strconv.Atoi(a)
+					},
+					func(a int) error { return nil })
+			}
+		})
+	}
+}
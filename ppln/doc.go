@@ -10,11 +10,54 @@
 // Each of the functions blocks the calling function until either the processing
 // is done (output was called on the last value) or until an error is returned.
 //
+// # Iterators
+//
+// [SerialIter] returns the outputs of a serial pipeline as an iterator,
+// running the pipeline while the iterator is consumed.
+//
+// # Flat-Map and Filter
+//
+// In [Serial] and [NonSerial] each input produces exactly one output.
+// [SerialFlatMap] and [NonSerialFlatMap] allow transform to produce zero or
+// more outputs for each input, while [SerialFilter] and [NonSerialFilter]
+// allow it to drop inputs.
+//
+// # Batching
+//
+// When transform is cheap, the synchronization around each item may
+// dominate the running time. [SerialBatch] and [NonSerialBatch] pull inputs
+// and push outputs in batches, paying the synchronization cost once per
+// batch.
+//
+// # Goroutine State
+//
+// [SerialState] and [NonSerialState] create a state object for each
+// goroutine, such as a scratch buffer or an accumulator, and pass it to
+// transform. An optional finalize function can then merge the
+// per-goroutine states into a single result.
+//
+// # Map-Reduce
+//
+// [MapReduce] aggregates values by key. Each goroutine reduces the values
+// it emits in its own map, and the maps are merged at the end, so no output
+// locking is involved.
+//
+// # Monitoring
+//
+// [SerialMonitor] and [NonSerialMonitor] collect performance metrics in a
+// [Monitor], such as the time spent in each stage, which helps tell whether
+// input, transform or output is the bottleneck.
+//
 // # Stopping
 //
 // Each user-function (input, transform, output) may return an error.
 // Returning a non-nil error stops the pipeline prematurely, and that
 // error is returned to the caller.
+// A panic in transform is recovered and returned as a [*PanicError].
+//
+// [SerialSkip] and [NonSerialSkip] instead skip items whose transform
+// failed, optionally retrying them first, and return all the collected
+// errors when done.
 //
 // # Experimental
 //
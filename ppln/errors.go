@@ -0,0 +1,151 @@
+package ppln
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"runtime/debug"
+)
+
+// PanicError is returned when a transform function panics.
+type PanicError struct {
+	I     int    // 0-based serial number of the item that caused the panic
+	Value any    // The value passed to panic
+	Stack []byte // Stack trace of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("item #%d: panic: %v\n\n%s", e.I, e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error, otherwise nil.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Calls transform and converts a panic to a PanicError.
+func safeTransform[T1 any, T2 any](
+	transform func(a T1, i int, g int) (T2, error),
+	a T1, i int, g int) (result T2, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{i, r, debug.Stack()}
+		}
+	}()
+	return transform(a, i, g)
+}
+
+// SerialSkip is like [Serial], but a failing transform does not stop the
+// process. Instead, transform is retried up to retries more times,
+// and if it still fails the item is skipped and no output is made for it.
+// Panics are treated as failures.
+//
+// Returns all transform errors joined with [errors.Join], in the order of
+// the inputs. Each error is annotated with its item's serial number.
+// If input or output return an error, the process stops and that error is
+// joined to the transform errors collected so far.
+func SerialSkip[T1 any, T2 any](
+	ngoroutines int,
+	retries int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, i int, g int) (T2, error),
+	output func(a T2) error) error {
+	if retries < 0 {
+		panic(fmt.Sprintf("bad number of retries: %d", retries))
+	}
+	var errs []error
+	err := Serial(
+		ngoroutines,
+		input,
+		func(a T1, i int, g int) (errItem[T2], error) {
+			t2, err := retry(transform, retries, a, i, g)
+			return errItem[T2]{data: t2, err: err}, nil
+		},
+		func(a errItem[T2]) error {
+			if a.err != nil {
+				errs = append(errs, a.err)
+				return nil
+			}
+			return output(a.data)
+		})
+	return errors.Join(append(errs, err)...)
+}
+
+// NonSerialSkip is like [NonSerial], but a failing transform does not stop
+// the process. Instead, transform is retried up to retries more times,
+// and if it still fails the item is skipped and no output is made for it.
+// Panics are treated as failures.
+//
+// Returns all transform errors joined with [errors.Join], in arbitrary
+// order. Each error is annotated with its item's serial number.
+// If input or output return an error, the process stops and that error is
+// joined to the transform errors collected so far.
+func NonSerialSkip[T1 any, T2 any](
+	ngoroutines int,
+	retries int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, g int) (T2, error),
+	output func(a T2) error) error {
+	if retries < 0 {
+		panic(fmt.Sprintf("bad number of retries: %d", retries))
+	}
+	itransform := func(a T1, i int, g int) (T2, error) {
+		return transform(a, g)
+	}
+	var errs []error
+	err := NonSerial(
+		ngoroutines,
+		indexInput(input),
+		func(a errItem[T1], g int) (errItem[T2], error) {
+			t2, err := retry(itransform, retries, a.data, a.i, g)
+			return errItem[T2]{data: t2, err: err}, nil
+		},
+		func(a errItem[T2]) error {
+			if a.err != nil {
+				errs = append(errs, a.err)
+				return nil
+			}
+			return output(a.data)
+		})
+	return errors.Join(append(errs, err)...)
+}
+
+// Calls transform up to 1+retries times until it succeeds.
+// Returns the last error, annotated with the item's serial number.
+func retry[T1 any, T2 any](
+	transform func(a T1, i int, g int) (T2, error),
+	retries int, a T1, i int, g int) (T2, error) {
+	var t2 T2
+	var err error
+	for range retries + 1 {
+		t2, err = safeTransform(transform, a, i, g)
+		if err == nil {
+			return t2, nil
+		}
+	}
+	if _, ok := err.(*PanicError); ok { // Already has the serial number.
+		return t2, err
+	}
+	return t2, fmt.Errorf("item #%d: %w", i, err)
+}
+
+// A value with its serial number, or the error that prevented its creation.
+type errItem[T any] struct {
+	data T
+	err  error
+	i    int
+}
+
+// Attaches serial numbers to the values of an input iterator.
+func indexInput[T any](input iter.Seq2[T, error]) iter.Seq2[errItem[T], error] {
+	return func(yield func(errItem[T], error) bool) {
+		i := 0
+		for t, err := range input {
+			if !yield(errItem[T]{data: t, i: i}, err) {
+				return
+			}
+			i++
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package ppln
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSerial_panic(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			err := Serial(
+				nt,
+				RangeInput(0, 1000),
+				func(a int, i int, g int) (int, error) {
+					if a == 500 {
+						panic("oh no")
+					}
+					return a, nil
+				},
+				func(a int) error { return nil })
+			var perr *PanicError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Serial(...)=%v, want PanicError", err)
+			}
+			if perr.I != 500 || perr.Value != "oh no" {
+				t.Fatalf("Serial(...)=(%v,%v), want (%v,%v)",
+					perr.I, perr.Value, 500, "oh no")
+			}
+			if !strings.Contains(string(perr.Stack), "TestSerial_panic") {
+				t.Fatalf("Serial(...) stack does not contain test name:\n%s",
+					perr.Stack)
+			}
+		})
+	}
+}
+
+func TestNonSerial_panic(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			err := NonSerial(
+				nt,
+				RangeInput(0, 1000),
+				func(a int, g int) (int, error) {
+					if a == 500 {
+						panic(fmt.Errorf("oh no"))
+					}
+					return a, nil
+				},
+				func(a int) error { return nil })
+			var perr *PanicError
+			if !errors.As(err, &perr) {
+				t.Fatalf("NonSerial(...)=%v, want PanicError", err)
+			}
+			if perr.I != 500 {
+				t.Fatalf("NonSerial(...).I=%v, want %v", perr.I, 500)
+			}
+			if errors.Unwrap(err) == nil {
+				t.Fatalf("Unwrap(NonSerial(...))=nil, want error")
+			}
+		})
+	}
+}
+
+func TestSerialBatch_panic(t *testing.T) {
+	for _, nt := range []int{1, 2, 4} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			err := SerialBatch(
+				nt, 7,
+				RangeInput(0, 1000),
+				func(a int, i int, g int) (int, error) {
+					if a == 500 {
+						panic("oh no")
+					}
+					return a, nil
+				},
+				func(a int) error { return nil })
+			var perr *PanicError
+			if !errors.As(err, &perr) {
+				t.Fatalf("SerialBatch(...)=%v, want PanicError", err)
+			}
+			if perr.I != 500 {
+				t.Fatalf("SerialBatch(...).I=%v, want %v", perr.I, 500)
+			}
+		})
+	}
+}
+
+func TestNonSerialBatch_panic(t *testing.T) {
+	for _, nt := range []int{1, 2, 4} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			err := NonSerialBatch(
+				nt, 7,
+				RangeInput(0, 1000),
+				func(a int, g int) (int, error) {
+					if a == 500 {
+						panic("oh no")
+					}
+					return a, nil
+				},
+				func(a int) error { return nil })
+			var perr *PanicError
+			if !errors.As(err, &perr) {
+				t.Fatalf("NonSerialBatch(...)=%v, want PanicError", err)
+			}
+			if perr.I != 500 {
+				t.Fatalf("NonSerialBatch(...).I=%v, want %v", perr.I, 500)
+			}
+		})
+	}
+}
+
+func TestSerialSkip(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			var result []int
+			err := SerialSkip(
+				nt,
+				0,
+				RangeInput(0, 100),
+				func(a int, i int, g int) (int, error) {
+					if a%10 == 3 {
+						return 0, fmt.Errorf("bad: %d", a)
+					}
+					if a%10 == 7 {
+						panic(a)
+					}
+					return a, nil
+				},
+				func(a int) error {
+					result = append(result, a)
+					return nil
+				})
+			if err == nil {
+				t.Fatalf("SerialSkip(...) succeeded, want error")
+			}
+			errs := err.(interface{ Unwrap() []error }).Unwrap()
+			if len(errs) != 20 {
+				t.Fatalf("SerialSkip(...) returned %d errors, want %d",
+					len(errs), 20)
+			}
+			if !strings.HasPrefix(errs[0].Error(), "item #3: bad: 3") {
+				t.Fatalf("SerialSkip(...) first error=%q, want %q",
+					errs[0], "item #3: bad: 3")
+			}
+			var perr *PanicError
+			if !errors.As(errs[1], &perr) || perr.I != 7 {
+				t.Fatalf("SerialSkip(...) second error=%v, want panic on #7",
+					errs[1])
+			}
+			var want []int
+			for i := range 100 {
+				if i%10 != 3 && i%10 != 7 {
+					want = append(want, i)
+				}
+			}
+			if !slices.Equal(result, want) {
+				t.Fatalf("SerialSkip(...)=%v, want %v", result, want)
+			}
+		})
+	}
+}
+
+func TestSerialSkip_retry(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			attempts := make([]atomic.Int32, 100)
+			var result []int
+			err := SerialSkip(
+				nt,
+				2,
+				RangeInput(0, 100),
+				func(a int, i int, g int) (int, error) {
+					n := attempts[a].Add(1)
+					if a%10 == 3 && n < 3 { // Succeeds on last retry.
+						return 0, fmt.Errorf("bad: %d", a)
+					}
+					if a%10 == 7 { // Never succeeds.
+						return 0, fmt.Errorf("bad: %d", a)
+					}
+					return a, nil
+				},
+				func(a int) error {
+					result = append(result, a)
+					return nil
+				})
+			errs := err.(interface{ Unwrap() []error }).Unwrap()
+			if len(errs) != 10 {
+				t.Fatalf("SerialSkip(...) returned %d errors, want %d",
+					len(errs), 10)
+			}
+			if len(result) != 90 {
+				t.Fatalf("SerialSkip(...) made %d outputs, want %d",
+					len(result), 90)
+			}
+			for i := range attempts {
+				want := int32(1)
+				if i%10 == 3 || i%10 == 7 {
+					want = 3
+				}
+				if got := attempts[i].Load(); got != want {
+					t.Fatalf("SerialSkip(...) attempts[%d]=%d, want %d",
+						i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNonSerialSkip(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			var result []int
+			err := NonSerialSkip(
+				nt,
+				1,
+				RangeInput(0, 100),
+				func(a int, g int) (int, error) {
+					if a%10 == 3 {
+						return 0, fmt.Errorf("bad: %d", a)
+					}
+					return a, nil
+				},
+				func(a int) error {
+					result = append(result, a)
+					return nil
+				})
+			errs := err.(interface{ Unwrap() []error }).Unwrap()
+			if len(errs) != 10 {
+				t.Fatalf("NonSerialSkip(...) returned %d errors, want %d",
+					len(errs), 10)
+			}
+			for _, err := range errs {
+				var i int
+				fmt.Sscanf(err.Error(), "item #%d:", &i)
+				if want := fmt.Sprintf("item #%d: bad: %d", i, i); err.Error() != want {
+					t.Fatalf("NonSerialSkip(...) error=%q, want %q", err, want)
+				}
+			}
+			if len(result) != 90 {
+				t.Fatalf("NonSerialSkip(...) made %d outputs, want %d",
+					len(result), 90)
+			}
+		})
+	}
+}
+
+func TestSerialSkip_outputError(t *testing.T) {
+	err := SerialSkip(
+		4,
+		0,
+		RangeInput(0, 100),
+		func(a int, i int, g int) (int, error) {
+			return a, nil
+		},
+		func(a int) error {
+			if a == 50 {
+				return fmt.Errorf("oh no")
+			}
+			return nil
+		})
+	if err == nil || err.Error() != "oh no" {
+		t.Fatalf("SerialSkip(...)=%v, want %q", err, "oh no")
+	}
+}
@@ -0,0 +1,92 @@
+package ppln
+
+import (
+	"iter"
+)
+
+// SerialFlatMap is like [Serial], but transform returns zero or more
+// outputs for each input.
+// Output is called on each returned value, in the order of the inputs,
+// and then in the order within each returned slice.
+func SerialFlatMap[T1 any, T2 any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, i int, g int) ([]T2, error),
+	output func(a T2) error) error {
+	return Serial(ngoroutines, input, transform, flatOutput(output))
+}
+
+// NonSerialFlatMap is like [NonSerial], but transform returns zero or more
+// outputs for each input.
+// Output is called on each returned value. Values returned from the same
+// call to transform are outputted consecutively in their order.
+func NonSerialFlatMap[T1 any, T2 any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, g int) ([]T2, error),
+	output func(a T2) error) error {
+	return NonSerial(ngoroutines, input, transform, flatOutput(output))
+}
+
+// SerialFilter is like [Serial], but transform also returns whether its
+// result should be kept. Output is called only on kept results,
+// in the order of the inputs.
+func SerialFilter[T1 any, T2 any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, i int, g int) (T2, bool, error),
+	output func(a T2) error) error {
+	return Serial(
+		ngoroutines,
+		input,
+		func(a T1, i int, g int) (filterItem[T2], error) {
+			t2, keep, err := transform(a, i, g)
+			return filterItem[T2]{t2, keep}, err
+		},
+		filterOutput(output))
+}
+
+// NonSerialFilter is like [NonSerial], but transform also returns whether
+// its result should be kept. Output is called only on kept results.
+func NonSerialFilter[T1 any, T2 any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, g int) (T2, bool, error),
+	output func(a T2) error) error {
+	return NonSerial(
+		ngoroutines,
+		input,
+		func(a T1, g int) (filterItem[T2], error) {
+			t2, keep, err := transform(a, g)
+			return filterItem[T2]{t2, keep}, err
+		},
+		filterOutput(output))
+}
+
+// Returns an output function that calls output on each element of a slice.
+func flatOutput[T any](output func(a T) error) func(a []T) error {
+	return func(a []T) error {
+		for _, t := range a {
+			if err := output(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// A value and whether it should be outputted.
+type filterItem[T any] struct {
+	data T
+	keep bool
+}
+
+// Returns an output function that calls output on kept values only.
+func filterOutput[T any](output func(a T) error) func(a filterItem[T]) error {
+	return func(a filterItem[T]) error {
+		if !a.keep {
+			return nil
+		}
+		return output(a.data)
+	}
+}
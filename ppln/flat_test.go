@@ -0,0 +1,144 @@
+package ppln
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func ExampleSerialFlatMap() {
+	lines := []string{"a b", "", "c d e"}
+	var words []string
+
+	SerialFlatMap(
+		2,
+		SliceInput(lines),
+		// Each line yields zero or more words.
+		func(a string, i, g int) ([]string, error) {
+			return strings.Fields(a), nil
+		},
+		func(a string) error {
+			words = append(words, a)
+			return nil
+		})
+
+	fmt.Println(words)
+
+	// Output:
+	// [a b c d e]
+}
+
+func TestSerialFlatMap(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			var got []int
+			err := SerialFlatMap(
+				nt,
+				RangeInput(0, 100),
+				func(a int, i int, g int) ([]int, error) {
+					return slices.Repeat([]int{a}, a%4), nil
+				},
+				func(a int) error {
+					got = append(got, a)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("SerialFlatMap(...) failed: %v", err)
+			}
+			var want []int
+			for i := range 100 {
+				want = append(want, slices.Repeat([]int{i}, i%4)...)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("SerialFlatMap(...)=%v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNonSerialFlatMap(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			var got []int
+			err := NonSerialFlatMap(
+				nt,
+				RangeInput(0, 100),
+				func(a int, g int) ([]int, error) {
+					return slices.Repeat([]int{a}, a%4), nil
+				},
+				func(a int) error {
+					got = append(got, a)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("NonSerialFlatMap(...) failed: %v", err)
+			}
+			var want []int
+			for i := range 100 {
+				want = append(want, slices.Repeat([]int{i}, i%4)...)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Fatalf("NonSerialFlatMap(...)=%v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSerialFilter(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			var got []int
+			err := SerialFilter(
+				nt,
+				RangeInput(0, 100),
+				func(a int, i int, g int) (int, bool, error) {
+					return a * a, a%3 == 0, nil
+				},
+				func(a int) error {
+					got = append(got, a)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("SerialFilter(...) failed: %v", err)
+			}
+			var want []int
+			for i := 0; i < 100; i += 3 {
+				want = append(want, i*i)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("SerialFilter(...)=%v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNonSerialFilter(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			var got []int
+			err := NonSerialFilter(
+				nt,
+				RangeInput(0, 100),
+				func(a int, g int) (int, bool, error) {
+					return a * a, a%3 == 0, nil
+				},
+				func(a int) error {
+					got = append(got, a)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("NonSerialFilter(...) failed: %v", err)
+			}
+			var want []int
+			for i := 0; i < 100; i += 3 {
+				want = append(want, i*i)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Fatalf("NonSerialFilter(...)=%v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package ppln
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+)
+
+// Returned by the pipeline's output when the consumer stops iterating.
+var errStopped = errors.New("iteration stopped")
+
+// SerialIter is like [Serial], but returns an iterator over the outputs
+// instead of calling an output function.
+// The pipeline runs while the iterator is consumed, and stops when the
+// consumer stops. Input is not called after the iteration returns.
+//
+// If input or transform return an error, the pipeline stops and the error
+// is yielded after the outputs that preceded it.
+func SerialIter[T1 any, T2 any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, i int, g int) (T2, error)) iter.Seq2[T2, error] {
+	if ngoroutines < 1 {
+		panic(fmt.Sprintf("bad number of goroutines: %d", ngoroutines))
+	}
+	return func(yield func(T2, error) bool) {
+		ch := make(chan T2, ngoroutines)
+		done := make(chan struct{})
+		wg := &sync.WaitGroup{}
+		var perr error
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(ch)
+			perr = Serial(ngoroutines, input, transform, func(a T2) error {
+				// Check done first, since a select between a ready send
+				// and a closed channel is random.
+				select {
+				case <-done:
+					return errStopped
+				default:
+				}
+				select {
+				case ch <- a:
+					return nil
+				case <-done:
+					return errStopped
+				}
+			})
+		}()
+		defer func() {
+			close(done)
+			wg.Wait()
+		}()
+
+		for a := range ch {
+			if !yield(a, nil) {
+				return
+			}
+		}
+		if perr != nil {
+			var zero T2
+			yield(zero, perr)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ppln
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSerialIter(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			i := 0
+			for a, err := range SerialIter(nt, RangeInput(0, 1000),
+				func(a int, i int, g int) (int, error) {
+					return a * a, nil
+				}) {
+				if err != nil {
+					t.Fatalf("SerialIter(...) failed: %v", err)
+				}
+				if a != i*i {
+					t.Fatalf("SerialIter(...)[%d]=%d, want %d", i, a, i*i)
+				}
+				i++
+			}
+			if i != 1000 {
+				t.Fatalf("SerialIter(...) yielded %d values, want 1000", i)
+			}
+		})
+	}
+}
+
+func TestSerialIter_break(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			pulled := &atomic.Int64{}
+			input := func(yield func(int, error) bool) {
+				for i := 0; ; i++ {
+					pulled.Add(1)
+					if !yield(i, nil) {
+						return
+					}
+				}
+			}
+			n := 0
+			for range SerialIter(nt, input,
+				func(a int, i int, g int) (int, error) {
+					return a, nil
+				}) {
+				n++
+				if n == 100 {
+					break
+				}
+			}
+			// The input is infinite, so returning means the pipeline stopped.
+			// Input should not be called after returning.
+			p := pulled.Load()
+			time.Sleep(time.Millisecond * 10)
+			if p2 := pulled.Load(); p2 != p {
+				t.Fatalf("SerialIter(...) pulled %d inputs after returning",
+					p2-p)
+			}
+		})
+	}
+}
+
+func TestSerialIter_error(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			bad := errors.New("bad")
+			var got []int
+			var errs []error
+			for a, err := range SerialIter(nt, RangeInput(0, 1000),
+				func(a int, i int, g int) (int, error) {
+					if a == 500 {
+						return 0, bad
+					}
+					return a, nil
+				}) {
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				got = append(got, a)
+			}
+			if len(errs) != 1 || errs[0] != bad {
+				t.Fatalf("SerialIter(...) errors=%v, want [%v]", errs, bad)
+			}
+			for i, a := range got {
+				if a != i {
+					t.Fatalf("SerialIter(...)[%d]=%d, want %d", i, a, i)
+				}
+			}
+			if len(got) > 500 {
+				t.Fatalf("SerialIter(...) yielded %d values, want at most 500",
+					len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package ppln
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// MapReduce runs a multi-goroutine keyed aggregation.
+//
+// Input is an iterator over the input values to be mapped.
+// It will be called in a thread-safe manner.
+// Mapper receives an input (a), a 0-based goroutine number (g) and an emit
+// function, and calls emit zero or more times with key-value pairs.
+// Reduce combines two values of the same key into one.
+// It may be called concurrently, on values emitted on different goroutines.
+//
+// Each goroutine aggregates its values in its own map, so no locking is
+// needed during mapping. The per-goroutine maps are merged when input is
+// exhausted, and the merged map is returned.
+//
+// If input or mapper return a non-nil error, the process stops and the
+// error is returned. A panic in mapper is returned as a [*PanicError].
+func MapReduce[T any, K comparable, V any](
+	ngoroutines int,
+	input iter.Seq2[T, error],
+	mapper func(a T, g int, emit func(k K, v V)) error,
+	reduce func(a, b V) V) (map[K]V, error) {
+	if ngoroutines < 1 {
+		panic(fmt.Sprintf("bad number of goroutines: %d", ngoroutines))
+	}
+	pull, pstop := iter.Pull2(input)
+	defer pstop()
+
+	ms := make([]map[K]V, ngoroutines)
+	imappers := make([]func(a T, i int, g int) (struct{}, error), ngoroutines)
+	for g := range ms {
+		m := map[K]V{}
+		emit := func(k K, v V) {
+			if old, ok := m[k]; ok {
+				v = reduce(old, v)
+			}
+			m[k] = v
+		}
+		ms[g] = m
+		imappers[g] = func(a T, i int, g int) (struct{}, error) {
+			return struct{}{}, mapper(a, g, emit)
+		}
+	}
+
+	ilock := &sync.Mutex{}
+	errs := make(chan error, ngoroutines)
+	stop := &atomic.Bool{}
+
+	i := 0
+	for g := 0; g < ngoroutines; g++ {
+		go func(g int) {
+			for {
+				if stop.Load() {
+					errs <- nil
+					return
+				}
+
+				ilock.Lock()
+				t, err, ok := pull()
+				ii := i
+				i++
+				ilock.Unlock()
+
+				if !ok {
+					errs <- nil
+					return
+				}
+				if err != nil {
+					stop.Store(true)
+					errs <- err
+					return
+				}
+
+				if _, err := safeTransform(imappers[g], t, ii, g); err != nil {
+					stop.Store(true)
+					errs <- err
+					return
+				}
+			}
+		}(g)
+	}
+
+	var err error
+	for g := 0; g < ngoroutines; g++ {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge into the largest map, to minimize insertions.
+	slices.SortFunc(ms, func(a, b map[K]V) int {
+		return len(b) - len(a)
+	})
+	result := ms[0]
+	for _, m := range ms[1:] {
+		for k, v := range m {
+			if old, ok := result[k]; ok {
+				v = reduce(old, v)
+			}
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+// MapReduceSorted is like [MapReduce], but returns an iterator over the
+// merged key-value pairs, ordered by key.
+func MapReduceSorted[T any, K cmp.Ordered, V any](
+	ngoroutines int,
+	input iter.Seq2[T, error],
+	mapper func(a T, g int, emit func(k K, v V)) error,
+	reduce func(a, b V) V) (iter.Seq2[K, V], error) {
+	m, err := MapReduce(ngoroutines, input, mapper, reduce)
+	if err != nil {
+		return nil, err
+	}
+	keys := slices.Sorted(maps.Keys(m))
+	return func(yield func(K, V) bool) {
+		for _, k := range keys {
+			if !yield(k, m[k]) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,104 @@
+package ppln
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func ExampleMapReduceSorted() {
+	lines := []string{"a b a", "c a", "b"}
+
+	counts, _ := MapReduceSorted(
+		2,
+		SliceInput(lines),
+		// Emit each word with a count of 1.
+		func(a string, g int, emit func(string, int)) error {
+			for _, w := range strings.Fields(a) {
+				emit(w, 1)
+			}
+			return nil
+		},
+		// Sum the counts of each word.
+		func(a, b int) int { return a + b })
+
+	for word, count := range counts {
+		fmt.Println(word, count)
+	}
+
+	// Output:
+	// a 3
+	// b 2
+	// c 1
+}
+
+func TestMapReduce(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			got, err := MapReduce(
+				nt,
+				RangeInput(0, 1000),
+				func(a int, g int, emit func(int, int)) error {
+					emit(a%7, a)
+					emit(-1, 1)
+					return nil
+				},
+				func(a, b int) int { return a + b })
+			if err != nil {
+				t.Fatalf("MapReduce(...) failed: %v", err)
+			}
+			want := map[int]int{-1: 1000}
+			for i := range 1000 {
+				want[i%7] += i
+			}
+			if !maps.Equal(got, want) {
+				t.Fatalf("MapReduce(...)=%v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMapReduce_error(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			_, err := MapReduce(
+				nt,
+				RangeInput(0, 1000),
+				func(a int, g int, emit func(int, int)) error {
+					if a == 500 {
+						return fmt.Errorf("oh no")
+					}
+					emit(a, a)
+					return nil
+				},
+				func(a, b int) int { return a + b })
+			if err == nil {
+				t.Fatalf("MapReduce(...) succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestMapReduce_panic(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			_, err := MapReduce(
+				nt,
+				RangeInput(0, 1000),
+				func(a int, g int, emit func(int, int)) error {
+					if a == 500 {
+						panic("oh no")
+					}
+					emit(a, a)
+					return nil
+				},
+				func(a, b int) int { return a + b })
+			var perr *PanicError
+			if !errors.As(err, &perr) || perr.I != 500 {
+				t.Fatalf("MapReduce(...)=%v, want panic on #500", err)
+			}
+		})
+	}
+}
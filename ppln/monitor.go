@@ -0,0 +1,160 @@
+package ppln
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/fluhus/gostuff/ptimer"
+)
+
+// A Monitor collects performance metrics of a running pipeline,
+// for finding its bottleneck.
+// Use with [SerialMonitor] or [NonSerialMonitor].
+// The zero value is ready for use.
+//
+// A Monitor's methods are safe for concurrent use, so its stats may be
+// inspected while the pipeline is running.
+type Monitor struct {
+	// If not nil, Inc is called on each output, in a thread-safe manner.
+	// Use [Monitor.Stats] in the timer's message function for printing the
+	// stats periodically.
+	Timer *ptimer.Timer
+
+	inputs, transforms, outputs atomic.Int64
+	inputWait, input            atomic.Int64
+	transform                   atomic.Int64
+	outputWait, output          atomic.Int64
+	maxPending                  atomic.Int64
+}
+
+// Stats is a snapshot of a pipeline's performance metrics.
+//
+// Durations are summed over all goroutines, so they may exceed the
+// pipeline's running time.
+type Stats struct {
+	Inputs     int // Number of items pulled from input
+	Transforms int // Number of completed transforms
+	Outputs    int // Number of items passed to output
+
+	InputWait  time.Duration // Time spent waiting for access to input
+	Input      time.Duration // Time spent in input
+	Transform  time.Duration // Time spent in transform
+	OutputWait time.Duration // Time spent waiting for access to output
+	Output     time.Duration // Time spent in output, including reordering
+
+	// Maximal number of results waiting for their turn to be outputted.
+	// Always 0 in non-serial pipelines.
+	MaxPending int
+}
+
+// Stats returns a snapshot of the collected metrics.
+func (m *Monitor) Stats() Stats {
+	return Stats{
+		Inputs:     int(m.inputs.Load()),
+		Transforms: int(m.transforms.Load()),
+		Outputs:    int(m.outputs.Load()),
+		InputWait:  time.Duration(m.inputWait.Load()),
+		Input:      time.Duration(m.input.Load()),
+		Transform:  time.Duration(m.transform.Load()),
+		OutputWait: time.Duration(m.outputWait.Load()),
+		Output:     time.Duration(m.output.Load()),
+		MaxPending: int(m.maxPending.Load()),
+	}
+}
+
+// String returns a one-line summary of the stats.
+func (s Stats) String() string {
+	return fmt.Sprintf(
+		"in: %d (wait %v, %v) tr: %d (%v) out: %d (wait %v, %v) pending: %d",
+		s.Inputs, s.InputWait, s.Input,
+		s.Transforms, s.Transform,
+		s.Outputs, s.OutputWait, s.Output,
+		s.MaxPending)
+}
+
+// The following methods are no-ops on a nil monitor, so that pipelines
+// can call them unconditionally.
+
+// Returns the current time, or zero if m is nil.
+func (m *Monitor) now() time.Time {
+	if m == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// Adds the time since t to d and returns the current time.
+func (m *Monitor) since(d *atomic.Int64, t time.Time) time.Time {
+	now := time.Now()
+	d.Add(int64(now.Sub(t)))
+	return now
+}
+
+// Records the time spent waiting for input since t, and returns the
+// current time.
+func (m *Monitor) inputWaited(t time.Time) time.Time {
+	if m == nil {
+		return t
+	}
+	return m.since(&m.inputWait, t)
+}
+
+// Records an input call that started at t, and returns the current time.
+// Ok is false if input was exhausted.
+func (m *Monitor) inputDone(t time.Time, ok bool) time.Time {
+	if m == nil {
+		return t
+	}
+	if ok {
+		m.inputs.Add(1)
+	}
+	return m.since(&m.input, t)
+}
+
+// Records a transform call that started at t, and returns the current time.
+func (m *Monitor) transformDone(t time.Time) time.Time {
+	if m == nil {
+		return t
+	}
+	m.transforms.Add(1)
+	return m.since(&m.transform, t)
+}
+
+// Records the time spent waiting for output since t, and returns the
+// current time.
+func (m *Monitor) outputWaited(t time.Time) time.Time {
+	if m == nil {
+		return t
+	}
+	return m.since(&m.outputWait, t)
+}
+
+// Records the time spent in output since t.
+func (m *Monitor) outputDone(t time.Time) {
+	if m == nil {
+		return
+	}
+	m.since(&m.output, t)
+}
+
+// Records a single output call. Should be called in a thread-safe manner.
+func (m *Monitor) outputted() {
+	if m == nil {
+		return
+	}
+	m.outputs.Add(1)
+	if m.Timer != nil {
+		m.Timer.Inc()
+	}
+}
+
+// Records the current number of pending results.
+func (m *Monitor) pending(n int) {
+	if m == nil {
+		return
+	}
+	if int64(n) > m.maxPending.Load() {
+		m.maxPending.Store(int64(n))
+	}
+}
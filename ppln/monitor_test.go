@@ -0,0 +1,139 @@
+package ppln
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluhus/gostuff/ptimer"
+)
+
+func TestSerialMonitor(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			n := 100
+			m := &Monitor{}
+			out := &strings.Builder{}
+			m.Timer = ptimer.NewFunc(func(i int) string {
+				return m.Stats().String()
+			})
+			m.Timer.W = out
+			err := SerialMonitor(
+				nt,
+				RangeInput(0, n),
+				func(a int, i int, g int) (int, error) {
+					if a == 0 {
+						// Let the following items finish first.
+						time.Sleep(time.Millisecond * 20)
+					}
+					time.Sleep(time.Millisecond * time.Duration(a%3))
+					return a, nil
+				},
+				func(a int) error {
+					return nil
+				},
+				m)
+			if err != nil {
+				t.Fatalf("SerialMonitor(...) failed: %v", err)
+			}
+			s := m.Stats()
+			if s.Inputs != n || s.Transforms != n || s.Outputs != n {
+				t.Fatalf("SerialMonitor(...) counts=(%d,%d,%d), want %d",
+					s.Inputs, s.Transforms, s.Outputs, n)
+			}
+			if s.Transform < time.Millisecond*time.Duration(n) {
+				t.Fatalf("SerialMonitor(...) transform time=%v, want >=%v",
+					s.Transform, time.Millisecond*time.Duration(n))
+			}
+			if nt == 1 && s.MaxPending != 0 {
+				t.Fatalf("SerialMonitor(...) max pending=%d, want 0",
+					s.MaxPending)
+			}
+			if nt > 1 && s.MaxPending < 1 {
+				t.Fatalf("SerialMonitor(...) max pending=%d, want >=1",
+					s.MaxPending)
+			}
+			if m.Timer.N != n {
+				t.Fatalf("SerialMonitor(...) timer count=%d, want %d",
+					m.Timer.N, n)
+			}
+			if !strings.Contains(out.String(), "in: ") {
+				t.Fatalf("SerialMonitor(...) timer printed %q, want stats",
+					out.String())
+			}
+		})
+	}
+}
+
+func TestSerialMonitor_inOrder(t *testing.T) {
+	for _, nt := range []int{2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			n := 100
+			// Each item's transform waits for the previous item's output,
+			// so results are never pending.
+			done := make([]chan struct{}, n)
+			for i := range done {
+				done[i] = make(chan struct{})
+			}
+			m := &Monitor{}
+			err := SerialMonitor(
+				nt,
+				RangeInput(0, n),
+				func(a int, i int, g int) (int, error) {
+					if i > 0 {
+						<-done[i-1]
+					}
+					return a, nil
+				},
+				func(a int) error {
+					close(done[a])
+					return nil
+				},
+				m)
+			if err != nil {
+				t.Fatalf("SerialMonitor(...) failed: %v", err)
+			}
+			if s := m.Stats(); s.MaxPending != 0 {
+				t.Fatalf("SerialMonitor(...) max pending=%d, want 0",
+					s.MaxPending)
+			}
+		})
+	}
+}
+
+func TestNonSerialMonitor(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			n := 100
+			m := &Monitor{}
+			err := NonSerialMonitor(
+				nt,
+				RangeInput(0, n),
+				func(a int, g int) (int, error) {
+					return a, nil
+				},
+				func(a int) error {
+					time.Sleep(time.Microsecond * 100)
+					return nil
+				},
+				m)
+			if err != nil {
+				t.Fatalf("NonSerialMonitor(...) failed: %v", err)
+			}
+			s := m.Stats()
+			if s.Inputs != n || s.Transforms != n || s.Outputs != n {
+				t.Fatalf("NonSerialMonitor(...) counts=(%d,%d,%d), want %d",
+					s.Inputs, s.Transforms, s.Outputs, n)
+			}
+			if s.Output < time.Microsecond*100*time.Duration(n) {
+				t.Fatalf("NonSerialMonitor(...) output time=%v, want >=%v",
+					s.Output, time.Microsecond*100*time.Duration(n))
+			}
+			if s.MaxPending != 0 {
+				t.Fatalf("NonSerialMonitor(...) max pending=%d, want 0",
+					s.MaxPending)
+			}
+		})
+	}
+}
@@ -18,22 +18,54 @@ import (
 // inputs.
 //
 // If one of the functions returns a non-nil error, the process stops and the
-// error is returned. Otherwise returns nil.
+// error is returned, after all goroutines have stopped. Otherwise returns nil.
+// A panic in transform stops the process and is returned as a [*PanicError].
 func NonSerial[T1 any, T2 any](
 	ngoroutines int,
 	input iter.Seq2[T1, error],
 	transform func(a T1, g int) (T2, error),
 	output func(a T2) error) error {
+	return nonSerial(ngoroutines, input, transform, output, nil)
+}
+
+// NonSerialMonitor is like [NonSerial], and collects performance metrics
+// in m.
+func NonSerialMonitor[T1 any, T2 any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, g int) (T2, error),
+	output func(a T2) error,
+	m *Monitor) error {
+	return nonSerial(ngoroutines, input, transform, output, m)
+}
+
+// Implements NonSerial with an optional monitor.
+func nonSerial[T1 any, T2 any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, g int) (T2, error),
+	output func(a T2) error,
+	m *Monitor) error {
 	if ngoroutines < 1 {
 		panic(fmt.Sprintf("bad number of goroutines: %d", ngoroutines))
 	}
 	pull, pstop := iter.Pull2(input)
 	defer pstop()
 
+	// Ignores the serial number, which is only used for reporting panics.
+	itransform := func(a T1, i int, g int) (T2, error) {
+		return transform(a, g)
+	}
+
 	// An optimization for a single thread.
 	if ngoroutines == 1 {
+		i := 0
 		for {
+			t := m.now()
 			t1, err, ok := pull()
+			t = m.inputDone(t, ok)
+			ii := i
+			i++
 
 			if !ok {
 				return nil
@@ -42,13 +74,16 @@ func NonSerial[T1 any, T2 any](
 				return err
 			}
 
-			t2, err := transform(t1, 0)
+			t2, err := safeTransform(itransform, t1, ii, 0)
 			if err != nil {
 				return err
 			}
+			t = m.transformDone(t)
 			if err := output(t2); err != nil {
 				return err
 			}
+			m.outputted()
+			m.outputDone(t)
 		}
 	}
 
@@ -57,6 +92,7 @@ func NonSerial[T1 any, T2 any](
 	errs := make(chan error, ngoroutines)
 	stop := &atomic.Bool{}
 
+	i := 0
 	for g := 0; g < ngoroutines; g++ {
 		go func(g int) {
 			for {
@@ -65,9 +101,14 @@ func NonSerial[T1 any, T2 any](
 					return
 				}
 
+				t := m.now()
 				ilock.Lock()
+				t = m.inputWaited(t)
 				t1, err, ok := pull()
+				ii := i
+				i++
 				ilock.Unlock()
+				t = m.inputDone(t, ok)
 
 				if !ok {
 					errs <- nil
@@ -79,29 +120,38 @@ func NonSerial[T1 any, T2 any](
 					return
 				}
 
-				t2, err := transform(t1, g)
+				t2, err := safeTransform(itransform, t1, ii, g)
 				if err != nil {
 					stop.Store(true)
 					errs <- err
 					return
 				}
+				t = m.transformDone(t)
 
 				olock.Lock()
+				t = m.outputWaited(t)
 				err = output(t2)
+				if err == nil {
+					m.outputted()
+				}
 				olock.Unlock()
 				if err != nil {
 					stop.Store(true)
 					errs <- err
 					return
 				}
+				m.outputDone(t)
 			}
 		}(g)
 	}
 
+	// Wait for all goroutines, so that none of them calls input or output
+	// after returning.
+	var err error
 	for g := 0; g < ngoroutines; g++ {
-		if err := <-errs; err != nil {
-			return err
+		if e := <-errs; e != nil && err == nil {
+			err = e
 		}
 	}
-	return nil
+	return err
 }
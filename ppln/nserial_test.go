@@ -3,7 +3,9 @@ package ppln
 import (
 	"fmt"
 	"math"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestNonSerial(t *testing.T) {
@@ -112,3 +114,37 @@ func TestNonSerial_outputError(t *testing.T) {
 }
 
 // TODO(amit): Error tests.
+
+func TestNonSerial_errorWaits(t *testing.T) {
+	for _, nt := range []int{2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			calls := &atomic.Int64{}
+			err := NonSerial(
+				nt,
+				RangeInput(0, 1000),
+				func(a int, g int) (int, error) {
+					if a == nt-1 {
+						// Fail while the other goroutines are busy.
+						time.Sleep(time.Millisecond * 5)
+						return 0, fmt.Errorf("oh no")
+					}
+					time.Sleep(time.Millisecond * 20)
+					calls.Add(1)
+					return a, nil
+				},
+				func(a int) error {
+					calls.Add(1)
+					return nil
+				})
+			if err == nil {
+				t.Fatalf("NonSerial(...) succeeded, want error")
+			}
+			want := calls.Load()
+			time.Sleep(time.Millisecond * 50)
+			if got := calls.Load(); got != want {
+				t.Fatalf("NonSerial(...) called functions %d times after returning",
+					got-want)
+			}
+		})
+	}
+}
@@ -20,12 +20,33 @@ import (
 // order of the input, in a thread-safe manner.
 //
 // If one of the functions returns a non-nil error, the process stops and the
-// error is returned. Otherwise returns nil.
+// error is returned, after all goroutines have stopped. Otherwise returns nil.
+// A panic in transform stops the process and is returned as a [*PanicError].
 func Serial[T1 any, T2 any](
 	ngoroutines int,
 	input iter.Seq2[T1, error],
 	transform func(a T1, i int, g int) (T2, error),
 	output func(a T2) error) error {
+	return serial(ngoroutines, input, transform, output, nil)
+}
+
+// SerialMonitor is like [Serial], and collects performance metrics in m.
+func SerialMonitor[T1 any, T2 any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, i int, g int) (T2, error),
+	output func(a T2) error,
+	m *Monitor) error {
+	return serial(ngoroutines, input, transform, output, m)
+}
+
+// Implements Serial with an optional monitor.
+func serial[T1 any, T2 any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	transform func(a T1, i int, g int) (T2, error),
+	output func(a T2) error,
+	m *Monitor) error {
 	if ngoroutines < 1 {
 		panic(fmt.Sprintf("bad number of goroutines: %d", ngoroutines))
 	}
@@ -36,7 +57,9 @@ func Serial[T1 any, T2 any](
 	if ngoroutines == 1 {
 		i := 0
 		for {
+			t := m.now()
 			t1, err, ok := pull()
+			t = m.inputDone(t, ok)
 			ii := i
 			i++
 
@@ -47,13 +70,16 @@ func Serial[T1 any, T2 any](
 				return err
 			}
 
-			t2, err := transform(t1, ii, 0)
+			t2, err := safeTransform(transform, t1, ii, 0)
 			if err != nil {
 				return err
 			}
+			t = m.transformDone(t)
 			if err := output(t2); err != nil {
 				return err
 			}
+			m.outputted()
+			m.outputDone(t)
 		}
 	}
 
@@ -76,11 +102,14 @@ func Serial[T1 any, T2 any](
 					return
 				}
 
+				t := m.now()
 				ilock.Lock()
+				t = m.inputWaited(t)
 				t1, err, ok := pull()
 				ii := i
 				i++
 				ilock.Unlock()
+				t = m.inputDone(t, ok)
 
 				if !ok {
 					errs <- nil
@@ -92,14 +121,16 @@ func Serial[T1 any, T2 any](
 					return
 				}
 
-				t2, err := transform(t1, ii, g)
+				t2, err := safeTransform(transform, t1, ii, g)
 				if err != nil {
 					stop.Store(true)
 					errs <- err
 					return
 				}
+				t = m.transformDone(t)
 
 				olock.Lock()
+				t = m.outputWaited(t)
 				items.put(serialItem[T2]{ii, t2})
 				for items.ok() {
 					err = output(items.pop())
@@ -109,18 +140,24 @@ func Serial[T1 any, T2 any](
 						errs <- err
 						return
 					}
+					m.outputted()
 				}
+				m.pending(items.data.Len())
 				olock.Unlock()
+				m.outputDone(t)
 			}
 		}(g)
 	}
 
+	// Wait for all goroutines, so that none of them calls input or output
+	// after returning.
+	var err error
 	for g := 0; g < ngoroutines; g++ {
-		if err := <-errs; err != nil {
-			return err
+		if e := <-errs; e != nil && err == nil {
+			err = e
 		}
 	}
-	return nil
+	return err
 }
 
 // General data with a serial number.
@@ -3,6 +3,7 @@ package ppln
 import (
 	"fmt"
 	"math/rand"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -119,3 +120,37 @@ func TestSerial_error(t *testing.T) {
 		})
 	}
 }
+
+func TestSerial_errorWaits(t *testing.T) {
+	for _, nt := range []int{2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			calls := &atomic.Int64{}
+			err := Serial(
+				nt,
+				RangeInput(0, 1000),
+				func(a int, i int, g int) (int, error) {
+					if a == nt-1 {
+						// Fail while the other goroutines are busy.
+						time.Sleep(time.Millisecond * 5)
+						return 0, fmt.Errorf("oh no")
+					}
+					time.Sleep(time.Millisecond * 20)
+					calls.Add(1)
+					return a, nil
+				},
+				func(a int) error {
+					calls.Add(1)
+					return nil
+				})
+			if err == nil {
+				t.Fatalf("Serial(...) succeeded, want error")
+			}
+			want := calls.Load()
+			time.Sleep(time.Millisecond * 50)
+			if got := calls.Load(); got != want {
+				t.Fatalf("Serial(...) called functions %d times after returning",
+					got-want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package ppln
+
+import (
+	"iter"
+)
+
+// SerialState is like [Serial], but gives each goroutine its own state
+// object.
+//
+// Init is called once per goroutine before processing starts,
+// with the 0-based goroutine number (g), and returns that goroutine's state.
+// Transform receives the state of the goroutine it runs on, so it may use it
+// without synchronization, for example as a scratch buffer or an
+// accumulator.
+// Finalize is an optional function (may be nil) that is called on each
+// state in goroutine order after all outputs were made, for example for
+// merging per-goroutine accumulators. It is not called if the pipeline
+// stopped with an error.
+func SerialState[T1 any, T2 any, S any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	init func(g int) S,
+	transform func(a T1, i int, s S) (T2, error),
+	output func(a T2) error,
+	finalize func(s S) error) error {
+	states := initStates(ngoroutines, init)
+	err := Serial(
+		ngoroutines,
+		input,
+		func(a T1, i int, g int) (T2, error) {
+			return transform(a, i, states[g])
+		},
+		output)
+	if err != nil {
+		return err
+	}
+	return finalizeStates(states, finalize)
+}
+
+// NonSerialState is like [NonSerial], but gives each goroutine its own state
+// object.
+//
+// Init is called once per goroutine before processing starts,
+// with the 0-based goroutine number (g), and returns that goroutine's state.
+// Transform receives the state of the goroutine it runs on, so it may use it
+// without synchronization, for example as a scratch buffer or an
+// accumulator.
+// Finalize is an optional function (may be nil) that is called on each
+// state in goroutine order after all outputs were made, for example for
+// merging per-goroutine accumulators. It is not called if the pipeline
+// stopped with an error.
+func NonSerialState[T1 any, T2 any, S any](
+	ngoroutines int,
+	input iter.Seq2[T1, error],
+	init func(g int) S,
+	transform func(a T1, s S) (T2, error),
+	output func(a T2) error,
+	finalize func(s S) error) error {
+	states := initStates(ngoroutines, init)
+	err := NonSerial(
+		ngoroutines,
+		input,
+		func(a T1, g int) (T2, error) {
+			return transform(a, states[g])
+		},
+		output)
+	if err != nil {
+		return err
+	}
+	return finalizeStates(states, finalize)
+}
+
+// Returns the initial states of n goroutines.
+func initStates[S any](n int, init func(g int) S) []S {
+	states := make([]S, max(n, 0))
+	for g := range states {
+		states[g] = init(g)
+	}
+	return states
+}
+
+// Calls finalize on each state, stopping at the first error.
+func finalizeStates[S any](states []S, finalize func(s S) error) error {
+	if finalize == nil {
+		return nil
+	}
+	for _, s := range states {
+		if err := finalize(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
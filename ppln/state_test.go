@@ -0,0 +1,115 @@
+package ppln
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fluhus/gostuff/hashx"
+	"github.com/fluhus/gostuff/hll"
+)
+
+func ExampleNonSerialState() {
+	ngoroutines := 4
+	total := hll.New(10, hashx.Int[int])
+
+	NonSerialState(
+		ngoroutines,
+		// Read/generate input data.
+		RangeInput(0, 100),
+		// Each goroutine gets its own counter.
+		func(g int) *hll.HLL[int] {
+			return hll.New(10, hashx.Int[int])
+		},
+		// Accumulate in goroutine-specific memory.
+		func(a int, h *hll.HLL[int]) (int, error) {
+			h.Add(a % 10)
+			return 0, nil // Unused.
+		},
+		// No outputs.
+		func(a int) error { return nil },
+		// Merge the counters of all goroutines.
+		func(h *hll.HLL[int]) error {
+			total.AddHLL(h)
+			return nil
+		})
+
+	fmt.Println("Distinct values:", total.ApproxCount())
+
+	// Output:
+	// Distinct values: 10
+}
+
+func TestSerialState(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			var inits []int
+			var result []int
+			sum := 0
+			err := SerialState(
+				nt,
+				RangeInput(0, 1000),
+				func(g int) *int {
+					inits = append(inits, g)
+					return new(int)
+				},
+				func(a int, i int, s *int) (int, error) {
+					*s += a
+					return a * a, nil
+				},
+				func(a int) error {
+					result = append(result, a)
+					return nil
+				},
+				func(s *int) error {
+					sum += *s
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("SerialState(...) failed: %v", err)
+			}
+			if len(inits) != nt {
+				t.Fatalf("SerialState(...) called init %d times, want %d",
+					len(inits), nt)
+			}
+			for i := range result {
+				if result[i] != i*i {
+					t.Errorf("result[%d]=%d, want %d", i, result[i], i*i)
+				}
+			}
+			if want := 999 * 1000 / 2; sum != want {
+				t.Fatalf("SerialState(...) sum=%d, want %d", sum, want)
+			}
+		})
+	}
+}
+
+func TestNonSerialState_error(t *testing.T) {
+	for _, nt := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(nt), func(t *testing.T) {
+			finalized := false
+			err := NonSerialState(
+				nt,
+				RangeInput(0, 1000),
+				func(g int) *int {
+					return new(int)
+				},
+				func(a int, s *int) (int, error) {
+					if a == 500 {
+						return 0, fmt.Errorf("oh no")
+					}
+					return a, nil
+				},
+				func(a int) error { return nil },
+				func(s *int) error {
+					finalized = true
+					return nil
+				})
+			if err == nil {
+				t.Fatalf("NonSerialState(...) succeeded, want error")
+			}
+			if finalized {
+				t.Fatalf("NonSerialState(...) called finalize after error")
+			}
+		})
+	}
+}